@@ -0,0 +1,13 @@
+# The personal access token and environment can be set in the configuration,
+# in the OSC_ACCESS_TOKEN and OSC_ENVIRONMENT environment variables or in a
+# profile of the credentials file, ~/.osc/credentials:
+#
+#   [default]
+#   pat = <personal access token>
+#   environment = prod
+#
+# The default profile is optional, so this also works in CI with only the
+# environment variables set.
+provider "osc" {
+  profile = "default"
+}
@@ -0,0 +1,3 @@
+resource "osc_ablindberg_adserver_frontend" "example" {
+  name = "example"
+}
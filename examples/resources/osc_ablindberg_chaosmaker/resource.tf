@@ -0,0 +1,3 @@
+resource "osc_ablindberg_chaosmaker" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_ablindberg_osc_vmaf_studio" "example" {
+  name = "example"
+}
@@ -0,0 +1,7 @@
+# An instance is imported by its name, optionally prefixed with the service id,
+# e.g. alexbj75-90stv/example
+#
+# Parameters the OSC API does not return, such as sensitive ones, are left out
+# of the imported state. Setting them in the configuration afterwards records
+# them without replacing the instance, changing any other parameter replaces it.
+terraform import osc_alexbj75_90stv.example example
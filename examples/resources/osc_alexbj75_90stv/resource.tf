@@ -0,0 +1,3 @@
+resource "osc_alexbj75_90stv" "example" {
+  name = "example"
+}
@@ -0,0 +1,13 @@
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_alexbj75_alextodolist" "example" {
+  name        = "example"
+  db_host     = "<db_host>"
+  db_port     = 1
+  db_user     = "<db_user>"
+  db_password = var.db_password
+  db_name     = "<db_name>"
+}
@@ -0,0 +1,4 @@
+resource "osc_alexbj75_food_recipe_collector_app" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
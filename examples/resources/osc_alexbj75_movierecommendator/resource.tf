@@ -0,0 +1,3 @@
+resource "osc_alexbj75_movierecommendator" "example" {
+  name = "example"
+}
@@ -0,0 +1,9 @@
+variable "signing_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_andersnas_nodecat" "example" {
+  name        = "example"
+  signing_key = var.signing_key
+}
@@ -0,0 +1,3 @@
+resource "osc_anderswassen_chaosproxy_config" "example" {
+  name = "example"
+}
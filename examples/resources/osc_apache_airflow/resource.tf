@@ -0,0 +1,3 @@
+resource "osc_apache_airflow" "example" {
+  name = "example"
+}
@@ -0,0 +1,10 @@
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_atmoz_sftp" "example" {
+  name     = "example"
+  username = "<username>"
+  password = var.password
+}
@@ -0,0 +1,5 @@
+resource "osc_automatisch_automatisch" "example" {
+  name         = "example"
+  redis_url    = "<redis_url>"
+  postgres_url = "<postgres_url>"
+}
@@ -0,0 +1,3 @@
+resource "osc_bbc_brave" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_binwiederhier_ntfy" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,9 @@
+variable "osc_access_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_birme_bucket_commander" "example" {
+  name             = "example"
+  osc_access_token = var.osc_access_token
+}
@@ -0,0 +1,3 @@
+resource "osc_birme_captcha_svc" "example" {
+  name = "example"
+}
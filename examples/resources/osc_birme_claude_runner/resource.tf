@@ -0,0 +1,5 @@
+resource "osc_birme_claude_runner" "example" {
+  name       = "example"
+  prompt     = "<prompt>"
+  source_url = "<source_url>"
+}
@@ -0,0 +1,4 @@
+resource "osc_birme_contact_form_svc" "example" {
+  name      = "example"
+  transport = "<transport>"
+}
@@ -0,0 +1,4 @@
+resource "osc_birme_goatcli" "example" {
+  name          = "example"
+  cmd_line_args = "<cmd_line_args>"
+}
@@ -0,0 +1,3 @@
+resource "osc_birme_lambda" "example" {
+  name = "example"
+}
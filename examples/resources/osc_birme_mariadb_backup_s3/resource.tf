@@ -0,0 +1,5 @@
+resource "osc_birme_mariadb_backup_s3" "example" {
+  name          = "example"
+  maria_db_url  = "<maria_db_url>"
+  cmd_line_args = "<cmd_line_args>"
+}
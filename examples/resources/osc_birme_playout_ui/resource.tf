@@ -0,0 +1,11 @@
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_birme_playout_ui" "example" {
+  name     = "example"
+  db_url   = "<db_url>"
+  username = "<username>"
+  password = var.password
+}
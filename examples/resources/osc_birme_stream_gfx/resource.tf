@@ -0,0 +1,3 @@
+resource "osc_birme_stream_gfx" "example" {
+  name = "example"
+}
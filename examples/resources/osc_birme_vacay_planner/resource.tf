@@ -0,0 +1,10 @@
+variable "jwt_secret" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_birme_vacay_planner" "example" {
+  name       = "example"
+  db_url     = "<db_url>"
+  jwt_secret = var.jwt_secret
+}
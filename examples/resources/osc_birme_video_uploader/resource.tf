@@ -0,0 +1,15 @@
+variable "s3_access_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "s3_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_birme_video_uploader" "example" {
+  name          = "example"
+  s3_access_key = var.s3_access_key
+  s3_secret_key = var.s3_secret_key
+}
@@ -0,0 +1,3 @@
+resource "osc_bjowestman_srt_stream_generator" "example" {
+  name = "example"
+}
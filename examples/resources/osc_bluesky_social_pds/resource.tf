@@ -0,0 +1,9 @@
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_bluesky_social_pds" "example" {
+  name           = "example"
+  admin_password = var.admin_password
+}
@@ -0,0 +1,3 @@
+resource "osc_bluewave_labs_checkmate" "example" {
+  name = "example"
+}
@@ -0,0 +1,10 @@
+variable "open_ai_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_boldare_openai_assistant" "example" {
+  name            = "example"
+  open_ai_api_key = var.open_ai_api_key
+  assistant_id    = "<assistant_id>"
+}
@@ -0,0 +1,10 @@
+variable "secret_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_burke_software_glitchtip" "example" {
+  name         = "example"
+  secret_key   = var.secret_key
+  database_url = "<database_url>"
+}
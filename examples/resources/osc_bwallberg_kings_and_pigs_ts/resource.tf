@@ -0,0 +1,3 @@
+resource "osc_bwallberg_kings_and_pigs_ts" "example" {
+  name = "example"
+}
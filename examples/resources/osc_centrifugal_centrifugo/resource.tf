@@ -0,0 +1,15 @@
+variable "token_hmac_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_centrifugal_centrifugo" "example" {
+  name                  = "example"
+  token_hmac_secret_key = var.token_hmac_secret_key
+  admin_password        = var.admin_password
+}
@@ -0,0 +1,3 @@
+resource "osc_chambana_net_docker_podcastgen" "example" {
+  name = "example"
+}
@@ -0,0 +1,5 @@
+resource "osc_channel_engine" "example" {
+  name = "example"
+  type = "<type>"
+  url  = "<url>"
+}
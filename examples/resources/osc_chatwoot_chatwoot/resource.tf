@@ -0,0 +1,11 @@
+variable "secret_key_base" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_chatwoot_chatwoot" "example" {
+  name            = "example"
+  database_url    = "<database_url>"
+  redis_url       = "<redis_url>"
+  secret_key_base = var.secret_key_base
+}
@@ -0,0 +1,3 @@
+resource "osc_clickhouse_clickhouse" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_dani_garcia_vaultwarden" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,3 @@
+resource "osc_dash_industry_forum_livesim2" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_datarhei_restreamer" "example" {
+  name = "example"
+}
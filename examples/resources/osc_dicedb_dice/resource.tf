@@ -0,0 +1,3 @@
+resource "osc_dicedb_dice" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_docusealco_docuseal" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_drawdb_io_drawdb" "example" {
+  name = "example"
+}
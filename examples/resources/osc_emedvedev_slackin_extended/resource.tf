@@ -0,0 +1,10 @@
+variable "slack_api_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_emedvedev_slackin_extended" "example" {
+  name               = "example"
+  slack_workspace_id = "<slack_workspace_id>"
+  slack_api_token    = var.slack_api_token
+}
@@ -0,0 +1,3 @@
+resource "osc_encore" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_ernestocarocca_hello_world" "example" {
+  name = "example"
+  text = "<text>"
+}
@@ -0,0 +1,3 @@
+resource "osc_ether_etherpad_lite" "example" {
+  name = "example"
+}
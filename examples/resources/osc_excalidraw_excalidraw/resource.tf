@@ -0,0 +1,3 @@
+resource "osc_excalidraw_excalidraw" "example" {
+  name = "example"
+}
@@ -0,0 +1,6 @@
+resource "osc_eyevinn_ad_normalizer" "example" {
+  name              = "example"
+  encore_url        = "<encore_url>"
+  ad_server_url     = "<ad_server_url>"
+  output_bucket_url = "<output_bucket_url>"
+}
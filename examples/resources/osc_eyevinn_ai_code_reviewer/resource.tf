@@ -0,0 +1,9 @@
+variable "open_ai_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_ai_code_reviewer" "example" {
+  name            = "example"
+  open_ai_api_key = var.open_ai_api_key
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_app_config_svc" "example" {
+  name      = "example"
+  redis_url = "<redis_url>"
+}
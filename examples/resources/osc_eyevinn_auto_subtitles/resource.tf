@@ -0,0 +1,9 @@
+variable "openaikey" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_auto_subtitles" "example" {
+  name      = "example"
+  openaikey = var.openaikey
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_cast_receiver" "example" {
+  name  = "example"
+  title = "<title>"
+}
@@ -0,0 +1,9 @@
+variable "keys" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_cat_validate" "example" {
+  name = "example"
+  keys = var.keys
+}
@@ -0,0 +1,6 @@
+resource "osc_eyevinn_channel_engine_bridge" "example" {
+  name      = "example"
+  source    = "<source>"
+  dest_type = "<dest_type>"
+  dest_url  = "<dest_url>"
+}
@@ -0,0 +1,9 @@
+variable "osc_access_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_channel_scheduler" "example" {
+  name             = "example"
+  osc_access_token = var.osc_access_token
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_chaos_stream_proxy" "example" {
+  name = "example"
+}
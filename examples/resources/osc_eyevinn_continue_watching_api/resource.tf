@@ -0,0 +1,4 @@
+resource "osc_eyevinn_continue_watching_api" "example" {
+  name       = "example"
+  redis_host = "<redis_host>"
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_dash_monitor" "example" {
+  name = "example"
+}
@@ -0,0 +1,5 @@
+resource "osc_eyevinn_db_backuper" "example" {
+  name         = "example"
+  operation    = "<operation>"
+  database_url = "<database_url>"
+}
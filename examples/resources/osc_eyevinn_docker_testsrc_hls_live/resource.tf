@@ -0,0 +1,3 @@
+resource "osc_eyevinn_docker_testsrc_hls_live" "example" {
+  name = "example"
+}
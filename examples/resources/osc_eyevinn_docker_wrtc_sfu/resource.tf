@@ -0,0 +1,9 @@
+variable "api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_docker_wrtc_sfu" "example" {
+  name    = "example"
+  api_key = var.api_key
+}
@@ -0,0 +1,5 @@
+resource "osc_eyevinn_encore_callback_listener" "example" {
+  name       = "example"
+  redis_url  = "<redis_url>"
+  encore_url = "<encore_url>"
+}
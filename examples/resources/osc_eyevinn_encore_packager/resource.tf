@@ -0,0 +1,23 @@
+variable "personal_access_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_encore_packager" "example" {
+  name                  = "example"
+  redis_url             = "<redis_url>"
+  output_folder         = "<output_folder>"
+  personal_access_token = var.personal_access_token
+  aws_access_key_id     = var.aws_access_key_id
+  aws_secret_access_key = var.aws_secret_access_key
+}
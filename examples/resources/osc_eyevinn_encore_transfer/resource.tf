@@ -0,0 +1,11 @@
+variable "osc_access_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_encore_transfer" "example" {
+  name             = "example"
+  redis_url        = "<redis_url>"
+  output           = "<output>"
+  osc_access_token = var.osc_access_token
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_encore_ui" "example" {
+  name       = "example"
+  encore_url = "<encore_url>"
+}
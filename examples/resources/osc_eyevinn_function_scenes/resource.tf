@@ -0,0 +1,3 @@
+resource "osc_eyevinn_function_scenes" "example" {
+  name = "example"
+}
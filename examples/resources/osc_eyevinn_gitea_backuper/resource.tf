@@ -0,0 +1,11 @@
+variable "gitea_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_gitea_backuper" "example" {
+  name        = "example"
+  operation   = "<operation>"
+  gitea_url   = "<gitea_url>"
+  gitea_token = var.gitea_token
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_golang_runner" "example" {
+  name       = "example"
+  source_url = "<source_url>"
+}
@@ -0,0 +1,16 @@
+variable "dest_access_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "dest_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_hls_copy_s3" "example" {
+  name            = "example"
+  cmd_line_args   = "<cmd_line_args>"
+  dest_access_key = var.dest_access_key
+  dest_secret_key = var.dest_secret_key
+}
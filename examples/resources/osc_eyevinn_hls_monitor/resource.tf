@@ -0,0 +1,3 @@
+resource "osc_eyevinn_hls_monitor" "example" {
+  name = "example"
+}
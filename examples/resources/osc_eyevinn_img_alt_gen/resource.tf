@@ -0,0 +1,9 @@
+variable "openai_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_img_alt_gen" "example" {
+  name           = "example"
+  openai_api_key = var.openai_api_key
+}
@@ -0,0 +1,11 @@
+variable "smb_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_intercom_manager" "example" {
+  name        = "example"
+  smb_url     = "<smb_url>"
+  smb_api_key = var.smb_api_key
+  db_url      = "<db_url>"
+}
@@ -0,0 +1,5 @@
+resource "osc_eyevinn_join_live" "example" {
+  name             = "example"
+  whip_gateway_url = "<whip_gateway_url>"
+  whep_gateway_url = "<whep_gateway_url>"
+}
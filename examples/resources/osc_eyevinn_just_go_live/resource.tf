@@ -0,0 +1,9 @@
+variable "osc_access_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_just_go_live" "example" {
+  name             = "example"
+  osc_access_token = var.osc_access_token
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_lambda_stitch" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_live_encoding" "example" {
+  name = "example"
+}
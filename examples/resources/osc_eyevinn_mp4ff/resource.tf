@@ -0,0 +1,4 @@
+resource "osc_eyevinn_mp4ff" "example" {
+  name          = "example"
+  cmd_line_args = "<cmd_line_args>"
+}
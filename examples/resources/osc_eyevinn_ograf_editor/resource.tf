@@ -0,0 +1,3 @@
+resource "osc_eyevinn_ograf_editor" "example" {
+  name = "example"
+}
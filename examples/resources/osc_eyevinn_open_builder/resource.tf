@@ -0,0 +1,9 @@
+variable "anthropic_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_open_builder" "example" {
+  name              = "example"
+  anthropic_api_key = var.anthropic_api_key
+}
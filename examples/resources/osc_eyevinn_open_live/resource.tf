@@ -0,0 +1,5 @@
+resource "osc_eyevinn_open_live" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+  strom_url    = "<strom_url>"
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_open_live_studio" "example" {
+  name          = "example"
+  open_live_url = "<open_live_url>"
+}
@@ -0,0 +1,5 @@
+resource "osc_eyevinn_openauth_pwd" "example" {
+  name            = "example"
+  user_db_url     = "<user_db_url>"
+  smtp_mailer_url = "<smtp_mailer_url>"
+}
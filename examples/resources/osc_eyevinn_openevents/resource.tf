@@ -0,0 +1,38 @@
+variable "nextauth_secret" {
+  type      = string
+  sensitive = true
+}
+
+variable "stripe_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "stripe_webhook_secret" {
+  type      = string
+  sensitive = true
+}
+
+variable "s3_access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "s3_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_openevents" "example" {
+  name                   = "example"
+  nextauth_secret        = var.nextauth_secret
+  stripe_secret_key      = var.stripe_secret_key
+  stripe_publishable_key = "<stripe_publishable_key>"
+  stripe_webhook_secret  = var.stripe_webhook_secret
+  s3_endpoint            = "<s3_endpoint>"
+  s3_region              = "<s3_region>"
+  s3_bucket_name         = "<s3_bucket_name>"
+  s3_access_key_id       = var.s3_access_key_id
+  s3_secret_access_key   = var.s3_secret_access_key
+  database_url           = "<database_url>"
+}
@@ -0,0 +1,10 @@
+variable "osc_access_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_osaas_client_ts" "example" {
+  name             = "example"
+  cmd_line_args    = "<cmd_line_args>"
+  osc_access_token = var.osc_access_token
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_pds_admin" "example" {
+  name    = "example"
+  pds_url = "<pds_url>"
+}
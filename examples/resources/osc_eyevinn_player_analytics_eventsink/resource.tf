@@ -0,0 +1,16 @@
+variable "aws_access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_player_analytics_eventsink" "example" {
+  name                  = "example"
+  sqs_queue_url         = "<sqs_queue_url>"
+  aws_access_key_id     = var.aws_access_key_id
+  aws_secret_access_key = var.aws_secret_access_key
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_preview_hls_service" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_qr_generator" "example" {
+  name     = "example"
+  goto_url = "<goto_url>"
+}
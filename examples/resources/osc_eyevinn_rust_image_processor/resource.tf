@@ -0,0 +1,3 @@
+resource "osc_eyevinn_rust_image_processor" "example" {
+  name = "example"
+}
@@ -0,0 +1,28 @@
+variable "source_access_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "source_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "dest_access_key" {
+  type      = string
+  sensitive = true
+}
+
+variable "dest_secret_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_s3_sync" "example" {
+  name              = "example"
+  cmd_line_args     = "<cmd_line_args>"
+  source_access_key = var.source_access_key
+  source_secret_key = var.source_secret_key
+  dest_access_key   = var.dest_access_key
+  dest_secret_key   = var.dest_secret_key
+}
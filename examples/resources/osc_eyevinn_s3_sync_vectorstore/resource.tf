@@ -0,0 +1,10 @@
+variable "openai_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_s3_sync_vectorstore" "example" {
+  name           = "example"
+  cmd_line_args  = "<cmd_line_args>"
+  openai_api_key = var.openai_api_key
+}
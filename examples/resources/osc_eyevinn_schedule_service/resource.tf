@@ -0,0 +1,17 @@
+variable "aws_access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_schedule_service" "example" {
+  name                  = "example"
+  table_prefix          = "<table_prefix>"
+  aws_access_key_id     = var.aws_access_key_id
+  aws_secret_access_key = var.aws_secret_access_key
+  aws_region            = "<aws_region>"
+}
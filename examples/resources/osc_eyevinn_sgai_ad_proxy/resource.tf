@@ -0,0 +1,6 @@
+resource "osc_eyevinn_sgai_ad_proxy" "example" {
+  name           = "example"
+  vast_endpoint  = "<vast_endpoint>"
+  origin_host    = "<origin_host>"
+  insertion_mode = "<insertion_mode>"
+}
@@ -0,0 +1,4 @@
+resource "osc_eyevinn_shaka_packager_s3" "example" {
+  name          = "example"
+  cmd_line_args = "<cmd_line_args>"
+}
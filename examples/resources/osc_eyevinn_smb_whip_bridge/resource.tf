@@ -0,0 +1,4 @@
+resource "osc_eyevinn_smb_whip_bridge" "example" {
+  name    = "example"
+  smb_url = "<smb_url>"
+}
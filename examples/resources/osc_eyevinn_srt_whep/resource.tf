@@ -0,0 +1,5 @@
+resource "osc_eyevinn_srt_whep" "example" {
+  name        = "example"
+  source_ip   = "<source_ip>"
+  source_port = 1
+}
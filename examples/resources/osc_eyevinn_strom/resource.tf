@@ -0,0 +1,3 @@
+resource "osc_eyevinn_strom" "example" {
+  name = "example"
+}
@@ -0,0 +1,24 @@
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "aws_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_tams_gateway" "example" {
+  name                  = "example"
+  db_url                = "<db_url>"
+  db_username           = "<db_username>"
+  db_password           = var.db_password
+  aws_access_key_id     = var.aws_access_key_id
+  aws_secret_access_key = var.aws_secret_access_key
+  s3_bucket             = "<s3_bucket>"
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_teleprompter" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_test_adserver" "example" {
+  name = "example"
+}
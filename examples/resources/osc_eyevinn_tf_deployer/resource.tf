@@ -0,0 +1,3 @@
+resource "osc_eyevinn_tf_deployer" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_eyevinn_wasm_runner" "example" {
+  name = "example"
+}
@@ -0,0 +1,16 @@
+variable "access_key_id" {
+  type      = string
+  sensitive = true
+}
+
+variable "secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_eyevinn_web_video_review" "example" {
+  name              = "example"
+  access_key_id     = var.access_key_id
+  secret_access_key = var.secret_access_key
+  bucket            = "<bucket>"
+}
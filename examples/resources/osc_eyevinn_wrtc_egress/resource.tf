@@ -0,0 +1,4 @@
+resource "osc_eyevinn_wrtc_egress" "example" {
+  name    = "example"
+  smb_url = "<smb_url>"
+}
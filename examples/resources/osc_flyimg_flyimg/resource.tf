@@ -0,0 +1,3 @@
+resource "osc_flyimg_flyimg" "example" {
+  name = "example"
+}
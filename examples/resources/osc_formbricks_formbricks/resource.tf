@@ -0,0 +1,4 @@
+resource "osc_formbricks_formbricks" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,11 @@
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_freescout_help_desk_freescout" "example" {
+  name           = "example"
+  db_url         = "<db_url>"
+  admin_email    = "<admin_email>"
+  admin_password = var.admin_password
+}
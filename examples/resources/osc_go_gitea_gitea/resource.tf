@@ -0,0 +1,4 @@
+resource "osc_go_gitea_gitea" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
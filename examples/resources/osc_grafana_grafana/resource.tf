@@ -0,0 +1,3 @@
+resource "osc_grafana_grafana" "example" {
+  name = "example"
+}
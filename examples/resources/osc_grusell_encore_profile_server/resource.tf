@@ -0,0 +1,3 @@
+resource "osc_grusell_encore_profile_server" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_gwuhaolin_livego" "example" {
+  name = "example"
+}
@@ -0,0 +1,10 @@
+variable "admin_secret" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_hasura_graphql_engine" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+  admin_secret = var.admin_secret
+}
@@ -0,0 +1,3 @@
+resource "osc_itzg_docker_minecraft_bedrock_server" "example" {
+  name = "example"
+}
@@ -0,0 +1,11 @@
+variable "rcon_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_itzg_docker_minecraft_server" "example" {
+  name          = "example"
+  accept_eula   = true
+  rcon_password = var.rcon_password
+  mode          = "survival"
+}
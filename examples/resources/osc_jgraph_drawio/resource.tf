@@ -0,0 +1,3 @@
+resource "osc_jgraph_drawio" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_joeldelpilar_bxf_manager" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_joeldelpilar_tic_tac_vue" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_juiceandthejoe_todo_list_vibe" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
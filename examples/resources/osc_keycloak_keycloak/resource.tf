@@ -0,0 +1,11 @@
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_keycloak_keycloak" "example" {
+  name           = "example"
+  database_url   = "<database_url>"
+  admin_user     = "<admin_user>"
+  admin_password = var.admin_password
+}
@@ -0,0 +1,4 @@
+resource "osc_knadh_listmonk" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
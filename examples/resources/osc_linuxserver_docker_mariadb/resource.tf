@@ -0,0 +1,9 @@
+variable "root_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_linuxserver_docker_mariadb" "example" {
+  name          = "example"
+  root_password = var.root_password
+}
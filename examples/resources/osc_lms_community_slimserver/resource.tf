@@ -0,0 +1,3 @@
+resource "osc_lms_community_slimserver" "example" {
+  name = "example"
+}
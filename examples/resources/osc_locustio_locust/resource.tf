@@ -0,0 +1,4 @@
+resource "osc_locustio_locust" "example" {
+  name           = "example"
+  locustfile_url = "<locustfile_url>"
+}
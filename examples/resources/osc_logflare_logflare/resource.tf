@@ -0,0 +1,4 @@
+resource "osc_logflare_logflare" "example" {
+  name                 = "example"
+  postgres_backend_url = "<postgres_backend_url>"
+}
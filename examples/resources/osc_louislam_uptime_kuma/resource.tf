@@ -0,0 +1,4 @@
+resource "osc_louislam_uptime_kuma" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
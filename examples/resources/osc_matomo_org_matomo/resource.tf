@@ -0,0 +1,3 @@
+resource "osc_matomo_org_matomo" "example" {
+  name = "example"
+}
@@ -0,0 +1,9 @@
+variable "master_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_meilisearch_meilisearch" "example" {
+  name       = "example"
+  master_key = var.master_key
+}
@@ -0,0 +1,3 @@
+resource "osc_mickael_kerjean_filestash" "example" {
+  name = "example"
+}
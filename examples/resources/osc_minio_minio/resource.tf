@@ -0,0 +1,3 @@
+resource "osc_minio_minio" "example" {
+  name = "example"
+}
@@ -0,0 +1,27 @@
+variable "slack_bot_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "slack_app_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "slack_signing_secret" {
+  type      = string
+  sensitive = true
+}
+
+variable "anthropic_api_key" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_mpociot_claude_code_slack_bot" "example" {
+  name                 = "example"
+  slack_bot_token      = var.slack_bot_token
+  slack_app_token      = var.slack_app_token
+  slack_signing_secret = var.slack_signing_secret
+  anthropic_api_key    = var.anthropic_api_key
+}
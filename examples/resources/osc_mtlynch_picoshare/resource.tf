@@ -0,0 +1,9 @@
+variable "shared_secret" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_mtlynch_picoshare" "example" {
+  name          = "example"
+  shared_secret = var.shared_secret
+}
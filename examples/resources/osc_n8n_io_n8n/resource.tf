@@ -0,0 +1,9 @@
+variable "runners_auth_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_n8n_io_n8n" "example" {
+  name               = "example"
+  runners_auth_token = var.runners_auth_token
+}
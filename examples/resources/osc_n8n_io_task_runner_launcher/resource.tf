@@ -0,0 +1,10 @@
+variable "auth_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_n8n_io_task_runner_launcher" "example" {
+  name            = "example"
+  task_broker_uri = "<task_broker_uri>"
+  auth_token      = var.auth_token
+}
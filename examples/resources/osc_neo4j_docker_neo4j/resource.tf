@@ -0,0 +1,3 @@
+resource "osc_neo4j_docker_neo4j" "example" {
+  name = "example"
+}
@@ -0,0 +1,10 @@
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_nextcloud_server" "example" {
+  name           = "example"
+  admin_user     = "<admin_user>"
+  admin_password = var.admin_password
+}
@@ -0,0 +1,3 @@
+resource "osc_nfrederiksen_hls_viewer" "example" {
+  name = "example"
+}
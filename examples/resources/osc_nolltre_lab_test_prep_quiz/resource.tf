@@ -0,0 +1,3 @@
+resource "osc_nolltre_lab_test_prep_quiz" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_olawalejuwonm_anomalydetector" "example" {
+  name = "example"
+}
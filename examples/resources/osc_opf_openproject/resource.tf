@@ -0,0 +1,4 @@
+resource "osc_opf_openproject" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
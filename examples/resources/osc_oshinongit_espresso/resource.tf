@@ -0,0 +1,3 @@
+resource "osc_oshinongit_espresso" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_oss_apps_dynamic_og" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_ossrs_srs" "example" {
+  name = "example"
+}
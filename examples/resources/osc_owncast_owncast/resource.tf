@@ -0,0 +1,3 @@
+resource "osc_owncast_owncast" "example" {
+  name = "example"
+}
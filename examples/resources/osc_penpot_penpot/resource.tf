@@ -0,0 +1,6 @@
+resource "osc_penpot_penpot" "example" {
+  name        = "example"
+  db_url      = "<db_url>"
+  db_username = "<db_username>"
+  redis_url   = "<redis_url>"
+}
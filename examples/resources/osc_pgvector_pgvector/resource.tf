@@ -0,0 +1,9 @@
+variable "postgres_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_pgvector_pgvector" "example" {
+  name              = "example"
+  postgres_password = var.postgres_password
+}
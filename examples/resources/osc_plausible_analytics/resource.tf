@@ -0,0 +1,5 @@
+resource "osc_plausible_analytics" "example" {
+  name               = "example"
+  postgre_sql_url    = "<postgre_sql_url>"
+  click_house_db_url = "<click_house_db_url>"
+}
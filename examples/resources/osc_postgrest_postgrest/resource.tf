@@ -0,0 +1,4 @@
+resource "osc_postgrest_postgrest" "example" {
+  name   = "example"
+  db_uri = "<db_uri>"
+}
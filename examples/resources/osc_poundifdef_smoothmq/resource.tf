@@ -0,0 +1,3 @@
+resource "osc_poundifdef_smoothmq" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_psumiya_option_insights" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_realeyes_media_moe_replay" "example" {
+  name = "example"
+}
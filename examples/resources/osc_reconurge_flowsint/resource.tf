@@ -0,0 +1,25 @@
+variable "auth_secret" {
+  type      = string
+  sensitive = true
+}
+
+variable "master_vault_key_v1" {
+  type      = string
+  sensitive = true
+}
+
+variable "neo4j_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_reconurge_flowsint" "example" {
+  name                = "example"
+  database_url        = "<database_url>"
+  redis_url           = "<redis_url>"
+  auth_secret         = var.auth_secret
+  master_vault_key_v1 = var.master_vault_key_v1
+  neo4j_uri_bolt      = "<neo4j_uri_bolt>"
+  neo4j_username      = "<neo4j_username>"
+  neo4j_password      = var.neo4j_password
+}
@@ -0,0 +1,3 @@
+resource "osc_restorecommerce_pdf_rendering_srv" "example" {
+  name = "example"
+}
@@ -0,0 +1,7 @@
+resource "osc_roundcube_roundcubemail" "example" {
+  name         = "example"
+  imap_address = "<imap_address>"
+  imap_port    = 1
+  smtp_address = "<smtp_address>"
+  smtp_port    = 1
+}
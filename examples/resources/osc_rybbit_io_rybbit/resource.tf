@@ -0,0 +1,25 @@
+variable "postgres_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "clickhouse_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "better_auth_secret" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_rybbit_io_rybbit" "example" {
+  name                = "example"
+  postgres_host       = "<postgres_host>"
+  postgres_user       = "<postgres_user>"
+  postgres_password   = var.postgres_password
+  postgres_db         = "<postgres_db>"
+  clickhouse_host     = "<clickhouse_host>"
+  clickhouse_password = var.clickhouse_password
+  better_auth_secret  = var.better_auth_secret
+}
@@ -0,0 +1,3 @@
+resource "osc_salesagility_suitecrm" "example" {
+  name = "example"
+}
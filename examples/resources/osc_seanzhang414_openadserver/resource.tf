@@ -0,0 +1,5 @@
+resource "osc_seanzhang414_openadserver" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+  redis_url    = "<redis_url>"
+}
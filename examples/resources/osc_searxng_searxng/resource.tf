@@ -0,0 +1,3 @@
+resource "osc_searxng_searxng" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_smrchy_rest_rsmq" "example" {
+  name      = "example"
+  redis_url = "<redis_url>"
+}
@@ -0,0 +1,3 @@
+resource "osc_srperens_uturn" "example" {
+  name = "example"
+}
@@ -0,0 +1,4 @@
+resource "osc_supercorp_ai_supergateway" "example" {
+  name       = "example"
+  mcp_server = "<mcp_server>"
+}
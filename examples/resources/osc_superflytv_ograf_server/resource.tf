@@ -0,0 +1,3 @@
+resource "osc_superflytv_ograf_server" "example" {
+  name = "example"
+}
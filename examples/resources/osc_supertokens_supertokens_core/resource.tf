@@ -0,0 +1,4 @@
+resource "osc_supertokens_supertokens_core" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,4 @@
+resource "osc_svensson00_spectercrm" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
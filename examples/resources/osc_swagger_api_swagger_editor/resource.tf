@@ -0,0 +1,3 @@
+resource "osc_swagger_api_swagger_editor" "example" {
+  name = "example"
+}
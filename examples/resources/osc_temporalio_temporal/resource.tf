@@ -0,0 +1,4 @@
+resource "osc_temporalio_temporal" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,4 @@
+resource "osc_tryghost_ghost" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,3 @@
+resource "osc_tuomoku_spx_gc" "example" {
+  name = "example"
+}
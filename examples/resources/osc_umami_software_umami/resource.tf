@@ -0,0 +1,4 @@
+resource "osc_umami_software_umami" "example" {
+  name            = "example"
+  postgres_db_url = "<postgres_db_url>"
+}
@@ -0,0 +1,16 @@
+variable "init_frontend_api_tokens" {
+  type      = list(string)
+  sensitive = true
+}
+
+variable "init_backend_api_tokens" {
+  type      = list(string)
+  sensitive = true
+}
+
+resource "osc_unleash_unleash" "example" {
+  name                     = "example"
+  database_url             = "<database_url>"
+  init_frontend_api_tokens = var.init_frontend_api_tokens
+  init_backend_api_tokens  = var.init_backend_api_tokens
+}
@@ -0,0 +1,10 @@
+variable "admin_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_usefathom_fathom" "example" {
+  name           = "example"
+  admin_email    = "<admin_email>"
+  admin_password = var.admin_password
+}
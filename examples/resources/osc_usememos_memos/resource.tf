@@ -0,0 +1,3 @@
+resource "osc_usememos_memos" "example" {
+  name = "example"
+}
@@ -0,0 +1,3 @@
+resource "osc_valkey_io_valkey" "example" {
+  name = "example"
+}
@@ -0,0 +1,11 @@
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "osc_wordpress_wordpress" "example" {
+  name        = "example"
+  db_host     = "<db_host>"
+  db_user     = "<db_user>"
+  db_password = var.db_password
+}
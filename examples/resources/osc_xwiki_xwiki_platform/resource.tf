@@ -0,0 +1,4 @@
+resource "osc_xwiki_xwiki_platform" "example" {
+  name         = "example"
+  database_url = "<database_url>"
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+// listServices returns all services in the OSC catalog.
+func listServices(ctx context.Context, osaasContext *oscClient) ([]osaasclient.Service, error) {
+	serviceURL := osaasContext.catalogURL("/service")
+
+	var services []osaasclient.Service
+	err := createFetch(ctx, osaasContext.retry, serviceURL, "GET", nil, &services,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+	if err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// getCatalogService returns a single service from the OSC catalog, or nil if
+// the catalog has no service with the given id.
+func getCatalogService(ctx context.Context, osaasContext *oscClient, serviceId string) (*osaasclient.Service, error) {
+	services, err := listServices(ctx, osaasContext)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		if service.ServiceId == serviceId {
+			return &service, nil
+		}
+	}
+	return nil, nil
+}
+
+// validatePersonalAccessToken makes a cheap authenticated call to the OSC API
+// to check that the personal access token is accepted.
+func validatePersonalAccessToken(ctx context.Context, osaasContext *oscClient) error {
+	serviceURL := osaasContext.catalogURL("/mysubscriptions")
+	return createFetch(ctx, osaasContext.retry, serviceURL, "GET", nil, nil,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+}
+
+var errServiceNotSubscribed = errors.New("service not found in your subscriptions")
+
+// getService returns a service from the subscriptions of the tenant.
+func getService(ctx context.Context, osaasContext *oscClient, serviceId string) (*osaasclient.Service, error) {
+	serviceURL := osaasContext.catalogURL("/mysubscriptions")
+
+	var services []osaasclient.Service
+	err := createFetch(ctx, osaasContext.retry, serviceURL, "GET", nil, &services,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, service := range services {
+		if service.ServiceId == serviceId {
+			return &service, nil
+		}
+	}
+	return nil, errServiceNotSubscribed
+}
+
+// activateService subscribes the tenant to a service.
+func activateService(ctx context.Context, osaasContext *oscClient, serviceId string) error {
+	serviceURL := osaasContext.catalogURL("/mysubscriptions")
+
+	body, _ := json.Marshal(osaasclient.Subscriptions{
+		Services: []string{serviceId},
+	})
+
+	return createFetch(ctx, osaasContext.retry.asIdempotent(), serviceURL, "POST", bytes.NewBuffer(body), nil,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+}
+
+// getServiceAccessToken fetches a new service access token, subscribing the
+// tenant to the service first if needed. Callers use the token cache through
+// oscClient.serviceAccessToken instead.
+func getServiceAccessToken(ctx context.Context, osaasContext *oscClient, serviceId string) (serviceToken, error) {
+	_, err := getService(ctx, osaasContext, serviceId)
+	if errors.Is(err, errServiceNotSubscribed) {
+		err = activateService(ctx, osaasContext, serviceId)
+	}
+	if err != nil {
+		return serviceToken{}, err
+	}
+
+	satURL := osaasContext.tokenURL("/servicetoken")
+
+	body, _ := json.Marshal(map[string]string{
+		"serviceId": serviceId,
+	})
+
+	var serviceAccessToken osaasclient.ServiceAccessToken
+	err = createFetch(ctx, osaasContext.retry.asIdempotent(), satURL, "POST", bytes.NewBuffer(body), &serviceAccessToken,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+	if err != nil {
+		return serviceToken{}, err
+	}
+	return serviceToken{token: serviceAccessToken.Token, expiry: tokenExpiry(serviceAccessToken)}, nil
+}
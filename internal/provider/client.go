@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+// oscClient is the provider data shared by all resources and data sources:
+// the OSC context together with the base URLs of the OSC APIs, how to retry
+// requests to them and the service access tokens in use.
+type oscClient struct {
+	*osaasclient.Context
+	catalogEndpoint string
+	tokenEndpoint   string
+	deployEndpoint  string
+	retry           retryPolicy
+	tokens          *tokenCache
+}
+
+// newOscClient creates the provider data for an OSC context. Base URLs left
+// empty default to the OSC APIs of the environment of the context.
+func newOscClient(osaasContext *osaasclient.Context, catalogURL string, tokenURL string, deployURL string, retry retryPolicy) *oscClient {
+	endpoint := func(url string, api string) string {
+		if url == "" {
+			return fmt.Sprintf("https://%s.svc.%s.osaas.io", api, osaasContext.GetEnvironment())
+		}
+		return strings.TrimSuffix(url, "/")
+	}
+	return &oscClient{
+		Context:         osaasContext,
+		catalogEndpoint: endpoint(catalogURL, "catalog"),
+		tokenEndpoint:   endpoint(tokenURL, "token"),
+		deployEndpoint:  endpoint(deployURL, "deploy"),
+		retry:           retry,
+		tokens:          newTokenCache(),
+	}
+}
+
+// catalogURL returns the URL of a path of the catalog API.
+func (c *oscClient) catalogURL(path string) string {
+	return c.catalogEndpoint + path
+}
+
+// tokenURL returns the URL of a path of the token API.
+func (c *oscClient) tokenURL(path string) string {
+	return c.tokenEndpoint + path
+}
+
+// deployURL returns the URL of a path of the deploy API.
+func (c *oscClient) deployURL(path string) string {
+	return c.deployEndpoint + path
+}
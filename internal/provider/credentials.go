@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultProfile = "default"
+
+// credentialsProfile holds the settings of a named profile in the OSC
+// credentials file.
+type credentialsProfile struct {
+	Pat         string
+	Environment string
+}
+
+// defaultCredentialsFile returns the path of the OSC credentials file,
+// ~/.osc/credentials, or an empty string if the home directory is unknown.
+func defaultCredentialsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".osc", "credentials")
+}
+
+// readCredentialsProfile reads a named profile from an OSC credentials file.
+// The file is in INI format with one section per profile:
+//
+//	[default]
+//	pat = <personal access token>
+//	environment = prod
+//
+// It returns nil if the file or the profile does not exist.
+func readCredentialsProfile(path string, profile string) (*credentialsProfile, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var found *credentialsProfile
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == profile && found == nil {
+				found = &credentialsProfile{}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+		if section != profile {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "pat":
+			found.Pat = strings.TrimSpace(value)
+		case "environment":
+			found.Environment = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// personalAccessTokenClaims decodes the claims of a personal access token,
+// which is a JWT, without verifying it. It returns nil if the token cannot
+// be decoded.
+func personalAccessTokenClaims(pat string) map[string]interface{} {
+	parts := strings.Split(pat, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	return claims
+}
+
+// personalAccessTokenTenant returns the quoted tenant a personal access token
+// was issued for, for use in diagnostics.
+func personalAccessTokenTenant(pat string) string {
+	claims := personalAccessTokenClaims(pat)
+	for _, claim := range []string{"customerId", "tenantId", "tenant", "teamId", "team", "sub"} {
+		if tenant, ok := claims[claim].(string); ok && tenant != "" {
+			return fmt.Sprintf("%q", tenant)
+		}
+	}
+	if claims == nil {
+		return "(unknown, the token is not a valid JWT)"
+	}
+	return "(unknown)"
+}
+
+// personalAccessTokenExpiry describes when a personal access token expired,
+// for use in diagnostics. It returns an empty string if it has not expired or
+// its expiry is unknown.
+func personalAccessTokenExpiry(pat string) string {
+	exp, ok := personalAccessTokenClaims(pat)["exp"].(float64)
+	if !ok {
+		return ""
+	}
+	expiry := time.Unix(int64(exp), 0).UTC()
+	if expiry.After(time.Now()) {
+		return ""
+	}
+	return fmt.Sprintf(" (it expired at %s)", expiry.Format(time.RFC3339))
+}
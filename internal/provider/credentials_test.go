@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCredentials = `# OSC credentials
+[default]
+pat = default-pat
+environment = prod
+
+; a second profile
+[ dev ]
+pat=dev-pat
+environment =dev
+region = eu
+
+[empty]
+`
+
+// writeCredentials writes an OSC credentials file with the given content to a
+// temporary directory and returns its path.
+func writeCredentials(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCredentialsProfile(t *testing.T) {
+	path := writeCredentials(t, testCredentials)
+	tests := []struct {
+		name    string
+		profile string
+		want    *credentialsProfile
+	}{
+		{"default profile", "default", &credentialsProfile{Pat: "default-pat", Environment: "prod"}},
+		{"spaces around section and keys", "dev", &credentialsProfile{Pat: "dev-pat", Environment: "dev"}},
+		{"profile without settings", "empty", &credentialsProfile{}},
+		{"unknown profile", "staging", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := readCredentialsProfile(path, test.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (test.want == nil) || (got != nil && *got != *test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadCredentialsProfileErrors(t *testing.T) {
+	got, err := readCredentialsProfile(filepath.Join(t.TempDir(), "missing"), "default")
+	if err != nil || got != nil {
+		t.Errorf("expected a missing file to be no profile, got %+v, %v", got, err)
+	}
+
+	path := writeCredentials(t, "[default]\npat = default-pat\nnot a setting\n")
+	_, err = readCredentialsProfile(path, "default")
+	if err == nil || !strings.Contains(err.Error(), ":3:") {
+		t.Errorf("expected an error naming line 3, got %v", err)
+	}
+}
+
+// testClaimsJWT returns an unsigned JWT with the given JSON claims.
+func testClaimsJWT(claims string) string {
+	encode := func(value string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return fmt.Sprintf("%s.%s.%s", encode(`{"alg":"none"}`), encode(claims), encode("signature"))
+}
+
+func TestPersonalAccessTokenTenant(t *testing.T) {
+	tests := []struct {
+		name string
+		pat  string
+		want string
+	}{
+		{"customer id", testClaimsJWT(`{"customerId":"eyevinn","sub":"user"}`), `"eyevinn"`},
+		{"tenant id", testClaimsJWT(`{"tenantId":"acme"}`), `"acme"`},
+		{"subject", testClaimsJWT(`{"sub":"user"}`), `"user"`},
+		{"empty claim skipped", testClaimsJWT(`{"customerId":"","team":"media"}`), `"media"`},
+		{"no tenant claim", testClaimsJWT(`{"exp":1}`), "(unknown)"},
+		{"not a JWT", "opaque", "(unknown, the token is not a valid JWT)"},
+		{"invalid payload", "a.!!!.c", "(unknown, the token is not a valid JWT)"},
+		{"payload is not JSON", testClaimsJWT(`not json`), "(unknown, the token is not a valid JWT)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := personalAccessTokenTenant(test.pat); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPersonalAccessTokenExpiry(t *testing.T) {
+	expired := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		pat  string
+		want string
+	}{
+		{"expired", testClaimsJWT(fmt.Sprintf(`{"exp":%d}`, expired.Unix())), " (it expired at 2020-01-02T03:04:05Z)"},
+		{"not expired", testClaimsJWT(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(time.Hour).Unix())), ""},
+		{"no exp claim", testClaimsJWT(`{"sub":"user"}`), ""},
+		{"not a JWT", "opaque", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := personalAccessTokenExpiry(test.pat); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// addServiceSecret stores a secret for a service, to be referenced from
+// instance parameters as {{secrets.<name>}}.
+func addServiceSecret(ctx context.Context, osaasContext *oscClient, serviceId string, secretName string, secretData string) error {
+	secretURL := osaasContext.deployURL(fmt.Sprintf("/mysecrets/%s", url.PathEscape(serviceId)))
+
+	body, _ := json.Marshal(map[string]string{
+		"secretName": secretName,
+		"secretData": secretData,
+	})
+
+	return createFetch(ctx, osaasContext.retry, secretURL, "POST", bytes.NewBuffer(body), nil,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+}
+
+// deleteServiceSecret removes a secret of a service. A secret that is already
+// gone is not an error.
+func deleteServiceSecret(ctx context.Context, osaasContext *oscClient, serviceId string, secretName string) error {
+	secretURL := osaasContext.deployURL(fmt.Sprintf("/mysecrets/%s/%s", url.PathEscape(serviceId), url.PathEscape(secretName)))
+
+	err := createFetch(ctx, osaasContext.retry, secretURL, "DELETE", nil, nil,
+		auth{"x-pat-jwt", fmt.Sprintf("Bearer %s", osaasContext.GetPersonalAccessToken())})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+type auth struct {
+	Header string
+	Value  string
+}
+
+const (
+	defaultMaxRetries = 3
+	defaultMaxBackoff = 30 * time.Second
+	initialBackoff    = time.Second
+)
+
+// retryPolicy decides how often and how long createFetch retries a request
+// that failed on a transient error.
+type retryPolicy struct {
+	maxRetries int
+	maxBackoff time.Duration
+	// idempotent marks a request as safe to repeat even if it may have
+	// reached the OSC API. GET, PUT and DELETE requests always are.
+	idempotent bool
+}
+
+// noRetry is for requests whose callers poll and retry on their own.
+var noRetry = retryPolicy{}
+
+// asIdempotent returns the policy for a request that is safe to repeat.
+func (p retryPolicy) asIdempotent() retryPolicy {
+	p.idempotent = true
+	return p
+}
+
+// createFetch mirrors the client-go helper of the same name, but honours the
+// request context and reports non-2xx responses as osaasclient.FetchError so
+// callers can tell a missing instance apart from other failures.
+//
+// Transient failures are retried with exponential backoff and jitter, as the
+// retry policy allows. A request that is not idempotent is only repeated when
+// the OSC API cannot have acted on it: it could not connect, or the request
+// was rate limited.
+func createFetch(ctx context.Context, retry retryPolicy, url string, method string, body *bytes.Buffer, target interface{}, auth auth) error {
+	var payload []byte
+	if body != nil {
+		payload = body.Bytes()
+	}
+	idempotent := retry.idempotent || method == "GET" || method == "PUT" || method == "DELETE"
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := fetchOnce(ctx, url, method, payload, target, auth)
+		if err == nil || attempt >= retry.maxRetries || ctx.Err() != nil || !isTransient(err, idempotent) {
+			return err
+		}
+
+		delay := backoff(attempt, retry.maxBackoff)
+		if retryAfter > 0 {
+			delay = min(retryAfter, retry.maxBackoff)
+		}
+		tflog.Debug(ctx, "Retrying OSC API request", map[string]interface{}{
+			"method":  method,
+			"url":     url,
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+			"error":   err.Error(),
+		})
+
+		if !sleep(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// sleep waits for the given time, or until the context is done, in which
+// case it returns false.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// fetchOnce makes a single request. Along with the error it returns how long
+// the OSC API asked to wait before the next attempt, if it did.
+func fetchOnce(ctx context.Context, url string, method string, payload []byte, target interface{}, auth auth) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(auth.Header, auth.Value)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	responseBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return 0, osaasclient.UnauthorizedError{}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), osaasclient.FetchError{HTTPCode: resp.StatusCode, Message: string(responseBytes)}
+	}
+
+	if target != nil {
+		value, ok := resp.Header["Content-Type"]
+		if ok && strings.HasPrefix(value[0], "application/json") {
+			if err := json.Unmarshal(responseBytes, target); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// isTransient reports whether a failed request is worth another attempt.
+func isTransient(err error, idempotent bool) bool {
+	var fetchErr osaasclient.FetchError
+	if errors.As(err, &fetchErr) {
+		switch fetchErr.HTTPCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+	if errors.As(err, &osaasclient.UnauthorizedError{}) {
+		return false
+	}
+
+	// Failing to connect means the request was never sent
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	// Anything else, e.g. a connection reset, may have happened after the OSC
+	// API acted on the request
+	var urlErr *neturl.Error
+	var netErr net.Error
+	return idempotent && (errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// isAmbiguous reports whether a failed request may still have been carried
+// out by the OSC API, i.e. the connection broke or a gateway or overloaded
+// backend gave up on it.
+func isAmbiguous(err error) bool {
+	var fetchErr osaasclient.FetchError
+	if errors.As(err, &fetchErr) {
+		switch fetchErr.HTTPCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return !isTransient(err, false) && isTransient(err, true)
+}
+
+// backoff returns the delay before retry number attempt+1: an exponentially
+// growing ceiling, capped at maxBackoff, of which a random half is waited.
+func backoff(attempt int, maxBackoff time.Duration) time.Duration {
+	ceiling := maxBackoff
+	if attempt < 30 && initialBackoff<<attempt < maxBackoff {
+		ceiling = initialBackoff << attempt
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return ceiling/2 + rand.N(ceiling/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date. It returns zero if the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// isNotFound reports whether the OSC API answered that the requested object
+// does not exist.
+func isNotFound(err error) bool {
+	var fetchErr osaasclient.FetchError
+	return errors.As(err, &fetchErr) && fetchErr.HTTPCode == http.StatusNotFound
+}
+
+// isUnauthorized reports whether the OSC API rejected the credentials of a
+// request.
+func isUnauthorized(err error) bool {
+	var fetchErr osaasclient.FetchError
+	return errors.As(err, &osaasclient.UnauthorizedError{}) ||
+		(errors.As(err, &fetchErr) && fetchErr.HTTPCode == http.StatusForbidden)
+}
+
+// errorDetail describes an error from the OSC API for a diagnostic, naming the
+// service and instance when the operation ran out of time.
+func errorDetail(err error, serviceId string, name string) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("Timed out waiting on instance %q of service %s: %s", name, serviceId, err.Error())
+	}
+	return err.Error()
+}
@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+// testRetry retries quickly so that the tests do not wait on backoff.
+var testRetry = retryPolicy{maxRetries: 3, maxBackoff: 10 * time.Millisecond}
+
+// newFlakyServer answers the first failures requests with the given status
+// and the rest with an empty JSON object. It counts the requests it gets.
+func newFlakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestCreateFetchRetriesRateLimitedRequest(t *testing.T) {
+	server, requests := newFlakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+
+	var target map[string]interface{}
+	start := time.Now()
+	err := createFetch(context.Background(), testRetry, server.URL, "POST", bytes.NewBufferString(`{}`), &target, auth{"x-jwt", "Bearer token"})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+	if target["ok"] != true {
+		t.Errorf("expected the response of the retry to be decoded, got %v", target)
+	}
+	// Retry-After asks for a second, max_backoff caps it
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected Retry-After to be capped at the maximum backoff, waited %s", elapsed)
+	}
+}
+
+func TestCreateFetchResendsBody(t *testing.T) {
+	var bodies []string
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		_, _ = body.ReadFrom(r.Body)
+		bodies = append(bodies, body.String())
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	err := createFetch(context.Background(), testRetry.asIdempotent(), server.URL, "POST", bytes.NewBufferString(`{"serviceId":"a"}`), nil, auth{"x-jwt", "Bearer token"})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] != `{"serviceId":"a"}` {
+		t.Errorf("expected the body to be sent on every attempt, got %q", bodies)
+	}
+}
+
+func TestCreateFetchRetryRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		policy   retryPolicy
+		status   int
+		requests int32
+	}{
+		{"GET on 502", "GET", testRetry, http.StatusBadGateway, 4},
+		{"GET on 503", "GET", testRetry, http.StatusServiceUnavailable, 4},
+		{"DELETE on 504", "DELETE", testRetry, http.StatusGatewayTimeout, 4},
+		{"POST on 503", "POST", testRetry, http.StatusServiceUnavailable, 1},
+		{"idempotent POST on 503", "POST", testRetry.asIdempotent(), http.StatusServiceUnavailable, 4},
+		{"POST on 429", "POST", testRetry, http.StatusTooManyRequests, 4},
+		{"GET on 500", "GET", testRetry, http.StatusInternalServerError, 1},
+		{"GET on 404", "GET", testRetry, http.StatusNotFound, 1},
+		{"GET on 401", "GET", testRetry, http.StatusUnauthorized, 1},
+		{"GET without retries", "GET", noRetry, http.StatusServiceUnavailable, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := newFlakyServer(t, 100, test.status, nil)
+
+			err := createFetch(context.Background(), test.policy, server.URL, test.method, nil, nil, auth{"x-jwt", "Bearer token"})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if requests.Load() != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, requests.Load())
+			}
+		})
+	}
+}
+
+// newResettingServer accepts connections, reads the request and closes the
+// connection without answering, as if it broke after the request was sent.
+func newResettingServer(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var requests atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			requests.Add(1)
+			_, _ = conn.Read(make([]byte, 4096))
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				_ = tcp.SetLinger(0)
+			}
+			_ = conn.Close()
+		}
+	}()
+	return fmt.Sprintf("http://%s", listener.Addr()), &requests
+}
+
+func TestCreateFetchConnectionReset(t *testing.T) {
+	t.Run("POST is not repeated", func(t *testing.T) {
+		url, requests := newResettingServer(t)
+
+		err := createFetch(context.Background(), testRetry, url, "POST", bytes.NewBufferString(`{}`), nil, auth{"x-jwt", "Bearer token"})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if !isAmbiguous(err) {
+			t.Errorf("expected a broken connection to be ambiguous, got %v", err)
+		}
+		if requests.Load() != 1 {
+			t.Errorf("expected 1 request, got %d", requests.Load())
+		}
+	})
+
+	t.Run("GET is retried", func(t *testing.T) {
+		url, requests := newResettingServer(t)
+
+		err := createFetch(context.Background(), testRetry, url, "GET", nil, nil, auth{"x-jwt", "Bearer token"})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if requests.Load() != 4 {
+			t.Errorf("expected 4 requests, got %d", requests.Load())
+		}
+	})
+}
+
+func TestCreateFetchRetriesPostThatCouldNotConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := fmt.Sprintf("http://%s", listener.Addr())
+	_ = listener.Close()
+
+	err = createFetch(context.Background(), noRetry, url, "POST", nil, nil, auth{"x-jwt", "Bearer token"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !isTransient(err, false) {
+		t.Errorf("expected a refused connection to be retried for any request, got %v", err)
+	}
+	if isAmbiguous(err) {
+		t.Errorf("expected a refused connection not to be ambiguous, got %v", err)
+	}
+}
+
+func TestCreateFetchStopsWhenContextIsDone(t *testing.T) {
+	server, requests := newFlakyServer(t, 100, http.StatusServiceUnavailable, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	policy := retryPolicy{maxRetries: 100, maxBackoff: time.Minute}
+	err := createFetch(ctx, policy, server.URL, "GET", nil, nil, auth{"x-jwt", "Bearer token"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() > 2 {
+		t.Errorf("expected the retries to stop with the context, got %d requests", requests.Load())
+	}
+}
+
+func TestIsAmbiguous(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{osaasclient.FetchError{HTTPCode: http.StatusBadGateway}, true},
+		{osaasclient.FetchError{HTTPCode: http.StatusServiceUnavailable}, true},
+		{osaasclient.FetchError{HTTPCode: http.StatusGatewayTimeout}, true},
+		{osaasclient.FetchError{HTTPCode: http.StatusTooManyRequests}, false},
+		{osaasclient.FetchError{HTTPCode: http.StatusBadRequest}, false},
+		{osaasclient.UnauthorizedError{}, false},
+		{&neturl.Error{Op: "Post", URL: "https://svc", Err: io.ErrUnexpectedEOF}, true},
+		{errors.New("invalid character"), false},
+	}
+	for _, test := range tests {
+		if got := isAmbiguous(test.err); got != test.want {
+			t.Errorf("isAmbiguous(%v) = %t, want %t", test.err, got, test.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		ceiling := min(initialBackoff<<min(attempt, 30), 30*time.Second)
+		for i := 0; i < 20; i++ {
+			delay := backoff(attempt, 30*time.Second)
+			if delay < ceiling/2 || delay > ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+	if delay := backoff(3, 0); delay != 0 {
+		t.Errorf("expected no backoff without a ceiling, got %s", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("7"); got != 7*time.Second {
+		t.Errorf("expected seconds to be parsed, got %s", got)
+	}
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 8*time.Second || got > 10*time.Second {
+		t.Errorf("expected an HTTP date to be parsed, got %s", got)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	for _, value := range []string{"", "0", "-1", "soon", past} {
+		if got := parseRetryAfter(value); got != 0 {
+			t.Errorf("parseRetryAfter(%q) = %s, want 0", value, got)
+		}
+	}
+}
+
+// newInstanceServer stands in for the catalog, token and service APIs of a
+// single service "svc", failing the given number of creates with the given
+// status. It counts the creates and the lookups of instance "mine".
+func newInstanceServer(t *testing.T, status int, failures int32, created bool) (*oscClient, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var creates, gets atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/mysubscriptions":
+			_, _ = fmt.Fprintf(w, `[{"serviceId":"svc","apiUrl":"%s/svc"}]`, server.URL)
+		case r.URL.Path == "/servicetoken":
+			_, _ = w.Write([]byte(`{"serviceId":"svc","token":"sat"}`))
+		case r.URL.Path == "/svc" && r.Method == "POST":
+			if creates.Add(1) <= failures {
+				w.WriteHeader(status)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"mine","url":"https://mine"}`))
+		case r.URL.Path == "/svc/mine":
+			gets.Add(1)
+			if !created {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"mine","url":"https://mine"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	osaasContext, err := osaasclient.NewContext(&osaasclient.ContextConfig{PersonalAccessToken: "pat", Environment: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newOscClient(osaasContext, server.URL, server.URL, server.URL, testRetry), &creates, &gets
+}
+
+func TestCreateInstanceAfterAmbiguousFailure(t *testing.T) {
+	t.Run("instance was created", func(t *testing.T) {
+		client, creates, _ := newInstanceServer(t, http.StatusServiceUnavailable, 1, true)
+
+		instance, err := createInstance(context.Background(), client, "svc", map[string]interface{}{"name": "mine"})
+		if err != nil {
+			t.Fatalf("expected the created instance to be found, got %v", err)
+		}
+		if instance["name"] != "mine" {
+			t.Errorf("expected instance mine, got %v", instance)
+		}
+		if creates.Load() != 1 {
+			t.Errorf("expected the instance to be created once, got %d creates", creates.Load())
+		}
+	})
+
+	t.Run("instance was not created", func(t *testing.T) {
+		client, creates, _ := newInstanceServer(t, http.StatusBadGateway, 1, false)
+
+		instance, err := createInstance(context.Background(), client, "svc", map[string]interface{}{"name": "mine"})
+		if err != nil {
+			t.Fatalf("expected the create to be retried, got %v", err)
+		}
+		if instance["name"] != "mine" {
+			t.Errorf("expected instance mine, got %v", instance)
+		}
+		if creates.Load() != 2 {
+			t.Errorf("expected 2 creates, got %d", creates.Load())
+		}
+	})
+
+	t.Run("failure that is not ambiguous", func(t *testing.T) {
+		client, creates, _ := newInstanceServer(t, http.StatusBadRequest, 1, true)
+
+		if _, err := createInstance(context.Background(), client, "svc", map[string]interface{}{"name": "mine"}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if creates.Load() != 1 {
+			t.Errorf("expected 1 create, got %d", creates.Load())
+		}
+	})
+}
+
+func TestCreateInstanceRetriesInOneLayer(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"rate limited", http.StatusTooManyRequests},
+		{"ambiguous", http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, creates, gets := newInstanceServer(t, test.status, 100, false)
+
+			if _, err := createInstance(context.Background(), client, "svc", map[string]interface{}{"name": "mine"}); err == nil {
+				t.Fatal("expected an error")
+			}
+			if want := int32(testRetry.maxRetries + 1); creates.Load() != want {
+				t.Errorf("expected %d creates, got %d", want, creates.Load())
+			}
+			if want := int32(testRetry.maxRetries); gets.Load() != want {
+				t.Errorf("expected a lookup before every retry, got %d lookups", gets.Load())
+			}
+		})
+	}
+}
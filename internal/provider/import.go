@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/numberplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+// unreadParametersKey is the private state key listing the input attributes
+// an import could not read back from the OSC API, e.g. sensitive parameters
+// or parameters the API does not return.
+const unreadParametersKey = "unread_parameters"
+
+const requiresReplaceDescription = "Changing this value replaces the instance, unless it was null in state because the instance was imported and the value could not be read back, or because the value was not set and only its default is filled in."
+
+// privateState is the part of the private state of a resource the plan
+// modifiers, Read and Update have in common.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// unreadParameters returns the input attributes an import could not read
+// back, which are still null in state.
+func unreadParameters(ctx context.Context, private privateState) []string {
+	data, _ := private.GetKey(ctx, unreadParametersKey)
+	var names []string
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &names)
+	}
+	return names
+}
+
+// unreadParametersValue encodes the unread input attributes for the private
+// state. No attributes remove the key.
+func unreadParametersValue(names []string) []byte {
+	if len(names) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(names)
+	return data
+}
+
+// importedNullAttributes returns the names of the attributes that are null
+// in state after an import filled in what the OSC API returned.
+func importedNullAttributes(values map[string]attr.Value) []string {
+	var names []string
+	for name, value := range values {
+		if value.IsNull() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// keepsInstance reports whether a planned value leaves the instance in place.
+// That is the case for an attribute that is null in state, either because an
+// import could not read it back, so the configured value is the one the
+// instance already has, or because it was not set and the plan only fills in
+// its default, which the service applied when the parameter was not sent.
+func keepsInstance(ctx context.Context, private privateState, attribute path.Path, stateValue attr.Value, configValue attr.Value) bool {
+	if !stateValue.IsNull() {
+		return false
+	}
+	return configValue.IsNull() || slices.Contains(unreadParameters(ctx, private), attribute.String())
+}
+
+// adoptParameters records in state the planned values of the named input
+// attributes that are null in state. A plan only updates an instance in place
+// to set those, every other change replaces it.
+func adoptParameters(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse, names ...string) {
+	unread, diags := adoptPlannedValues(ctx, names, unreadParameters(ctx, req.Private), req.Plan, &resp.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(unread))...)
+}
+
+// adoptPlannedValues copies the named attributes that are null in state but
+// set in the plan to the state and returns the unread attributes that are
+// still null.
+func adoptPlannedValues(ctx context.Context, names []string, unread []string, plan tfsdk.Plan, state *tfsdk.State) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var stillUnread []string
+	for _, name := range names {
+		var planned, current attr.Value
+		diags.Append(plan.GetAttribute(ctx, path.Root(name), &planned)...)
+		diags.Append(state.GetAttribute(ctx, path.Root(name), &current)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+		switch {
+		case current.IsNull() && !planned.IsNull():
+			diags.Append(state.SetAttribute(ctx, path.Root(name), planned)...)
+		case planned.IsNull() && slices.Contains(unread, name):
+			stillUnread = append(stillUnread, name)
+		}
+	}
+	return stillUnread, diags
+}
+
+// The plan modifiers of the input attributes: OSC instances cannot be changed
+// in place, so a changed attribute replaces the instance, except for setting
+// an attribute an import could not read back or filling in a default.
+
+func requiresReplaceString() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !keepsInstance(ctx, req.Private, req.Path, req.StateValue, req.ConfigValue)
+	}, requiresReplaceDescription, requiresReplaceDescription)
+}
+
+func requiresReplaceBool() planmodifier.Bool {
+	return boolplanmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.BoolRequest, resp *boolplanmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !keepsInstance(ctx, req.Private, req.Path, req.StateValue, req.ConfigValue)
+	}, requiresReplaceDescription, requiresReplaceDescription)
+}
+
+func requiresReplaceInt64() planmodifier.Int64 {
+	return int64planmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !keepsInstance(ctx, req.Private, req.Path, req.StateValue, req.ConfigValue)
+	}, requiresReplaceDescription, requiresReplaceDescription)
+}
+
+func requiresReplaceNumber() planmodifier.Number {
+	return numberplanmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.NumberRequest, resp *numberplanmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !keepsInstance(ctx, req.Private, req.Path, req.StateValue, req.ConfigValue)
+	}, requiresReplaceDescription, requiresReplaceDescription)
+}
+
+func requiresReplaceList() planmodifier.List {
+	return listplanmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !keepsInstance(ctx, req.Private, req.Path, req.StateValue, req.ConfigValue)
+	}, requiresReplaceDescription, requiresReplaceDescription)
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testPrivateState is a private state listing the given unread attributes.
+type testPrivateState []string
+
+func (p testPrivateState) GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics) {
+	if key != unreadParametersKey {
+		return nil, nil
+	}
+	return unreadParametersValue(p), nil
+}
+
+func TestKeepsInstance(t *testing.T) {
+	tests := []struct {
+		name        string
+		unread      testPrivateState
+		stateValue  attr.Value
+		configValue attr.Value
+		want        bool
+	}{
+		{"changed", nil, types.StringValue("old"), types.StringValue("new"), false},
+		{"set after create", nil, types.StringNull(), types.StringValue("new"), false},
+		{"set after import", testPrivateState{"password"}, types.StringNull(), types.StringValue("new"), true},
+		{"changed after import", testPrivateState{"password"}, types.StringValue("old"), types.StringValue("new"), false},
+		{"other attribute unread", testPrivateState{"token"}, types.StringNull(), types.StringValue("new"), false},
+		{"list set after import", testPrivateState{"password"}, types.ListNull(types.StringType), types.ListValueMust(types.StringType, []attr.Value{types.StringValue("new")}), true},
+		{"default filled in", nil, types.StringNull(), types.StringNull(), true},
+		{"removed with a default", nil, types.StringValue("old"), types.StringNull(), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := keepsInstance(context.Background(), test.unread, path.Root("password"), test.stateValue, test.configValue); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRequiresReplaceWithoutPrivateState(t *testing.T) {
+	req := planmodifier.StringRequest{
+		Path:        path.Root("password"),
+		StateValue:  types.StringNull(),
+		PlanValue:   types.StringValue("new"),
+		ConfigValue: types.StringValue("new"),
+		State:       tfsdk.State{Raw: tftypes.NewValue(tftypes.Object{}, map[string]tftypes.Value{})},
+		Plan:        tfsdk.Plan{Raw: tftypes.NewValue(tftypes.Object{}, map[string]tftypes.Value{})},
+	}
+	resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+	requiresReplaceString().PlanModifyString(context.Background(), req, resp)
+	if !resp.RequiresReplace {
+		t.Error("expected a change to a resource that was not imported to replace it")
+	}
+}
+
+func TestImportedNullAttributes(t *testing.T) {
+	got := importedNullAttributes(map[string]attr.Value{
+		"token":    types.StringNull(),
+		"url":      types.StringValue("https://example.com"),
+		"password": types.StringNull(),
+		"port":     types.Int64Value(8080),
+	})
+	if want := []string{"password", "token"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if value := unreadParametersValue(nil); value != nil {
+		t.Errorf("expected no unread attributes to remove the key, got %q", value)
+	}
+}
+
+func TestAdoptPlannedValues(t *testing.T) {
+	ctx := context.Background()
+	testSchema := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"name":     schema.StringAttribute{Required: true},
+			"password": schema.StringAttribute{Optional: true},
+			"token":    schema.StringAttribute{Optional: true},
+			"origins":  schema.ListAttribute{Optional: true, ElementType: types.StringType},
+			"user":     schema.StringAttribute{Optional: true, Computed: true},
+		},
+	}
+	objectType := testSchema.Type().TerraformType(ctx)
+	origins := tftypes.List{ElementType: tftypes.String}
+
+	plan := tfsdk.Plan{Schema: testSchema, Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+		"name":     tftypes.NewValue(tftypes.String, "example"),
+		"password": tftypes.NewValue(tftypes.String, "secret"),
+		"token":    tftypes.NewValue(tftypes.String, nil),
+		"origins":  tftypes.NewValue(origins, []tftypes.Value{tftypes.NewValue(tftypes.String, "https://example.com")}),
+		"user":     tftypes.NewValue(tftypes.String, "postgres"),
+	})}
+	state := tfsdk.State{Schema: testSchema, Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+		"name":     tftypes.NewValue(tftypes.String, "example"),
+		"password": tftypes.NewValue(tftypes.String, nil),
+		"token":    tftypes.NewValue(tftypes.String, nil),
+		"origins":  tftypes.NewValue(origins, nil),
+		"user":     tftypes.NewValue(tftypes.String, nil),
+	})}
+
+	unread, diags := adoptPlannedValues(ctx, []string{"password", "token", "origins", "user"}, []string{"origins", "password", "token"}, plan, &state)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := []string{"token"}; !slices.Equal(unread, want) {
+		t.Errorf("expected the attributes left out of the configuration to stay unread, got %q", unread)
+	}
+
+	var password, user types.String
+	diags = state.GetAttribute(ctx, path.Root("password"), &password)
+	if diags.HasError() || password.ValueString() != "secret" {
+		t.Errorf("expected the configured password in state, got %s", password)
+	}
+	diags = state.GetAttribute(ctx, path.Root("user"), &user)
+	if diags.HasError() || user.ValueString() != "postgres" {
+		t.Errorf("expected the default user in state, got %s", user)
+	}
+	var originList types.List
+	diags = state.GetAttribute(ctx, path.Root("origins"), &originList)
+	if diags.HasError() || len(originList.Elements()) != 1 {
+		t.Errorf("expected the configured origins in state, got %s", originList)
+	}
+}
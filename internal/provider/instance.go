@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// createInstance creates an instance of a service. It is the only layer that
+// retries the create: a failure the OSC API cannot have acted on is retried
+// as the retry policy allows, and so is one that leaves it unknown whether
+// the instance was created, provided the instance has a name to look it up
+// by. Before every retry it looks for the instance and returns it when it
+// turns out to exist, so that it is not created twice.
+func createInstance(ctx context.Context, osaasContext *oscClient, serviceId string, body map[string]interface{}) (map[string]interface{}, error) {
+	service, err := getService(ctx, osaasContext, serviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyBytes, _ := json.Marshal(body)
+	name, _ := body["name"].(string)
+	retry := osaasContext.retry
+
+	var instance map[string]interface{}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && name != "" {
+			existing, getErr := getInstance(ctx, osaasContext, serviceId, name)
+			if getErr != nil {
+				break
+			}
+			if existing != nil {
+				return existing, nil
+			}
+		}
+
+		var retryAfter time.Duration
+		instance = nil
+		err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+			var fetchErr error
+			retryAfter, fetchErr = fetchOnce(ctx, service.ApiUrl, "POST", bodyBytes, &instance, auth{"x-jwt", fmt.Sprintf("Bearer %s", token)})
+			return fetchErr
+		})
+		if err == nil || attempt >= retry.maxRetries || ctx.Err() != nil {
+			break
+		}
+		if !isTransient(err, false) && !(isAmbiguous(err) && name != "") {
+			break
+		}
+
+		delay := backoff(attempt, retry.maxBackoff)
+		if retryAfter > 0 {
+			delay = min(retryAfter, retry.maxBackoff)
+		}
+		tflog.Debug(ctx, "Retrying instance create", map[string]interface{}{
+			"service": serviceId,
+			"name":    name,
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+			"error":   err.Error(),
+		})
+		if !sleep(ctx, delay) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reason, ok := instance["reason"].(string); ok {
+		return nil, errors.New(reason)
+	}
+	return instance, nil
+}
+
+// removeInstance removes an instance of a service. An instance that is
+// already gone is not an error.
+func removeInstance(ctx context.Context, osaasContext *oscClient, serviceId string, name string) error {
+	service, err := getService(ctx, osaasContext, serviceId)
+	if err != nil {
+		return err
+	}
+
+	instanceURL := fmt.Sprintf("%s/%s", service.ApiUrl, name)
+
+	err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+		return createFetch(ctx, osaasContext.retry, instanceURL, "DELETE", nil, nil, auth{"x-jwt", fmt.Sprintf("Bearer %s", token)})
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// getInstance fetches an instance of a service. It returns nil without an
+// error when the instance no longer exists.
+func getInstance(ctx context.Context, osaasContext *oscClient, serviceId string, name string) (map[string]interface{}, error) {
+	service, err := getService(ctx, osaasContext, serviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceURL := fmt.Sprintf("%s/%s", service.ApiUrl, name)
+
+	var instance map[string]interface{}
+	err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+		return createFetch(ctx, osaasContext.retry, instanceURL, "GET", nil, &instance, auth{"x-jwt", fmt.Sprintf("Bearer %s", token)})
+	})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if instance == nil || instance["name"] == nil {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+type instancePort struct {
+	ExternalIP   string `json:"externalIp"`
+	ExternalPort int    `json:"externalPort"`
+	InternalPort int    `json:"internalPort"`
+	Protocol     string `json:"protocol"`
+}
+
+type InstancePortModel struct {
+	InternalPort types.Int32  `tfsdk:"internal_port"`
+	ExternalPort types.Int32  `tfsdk:"external_port"`
+	ExternalIp   types.String `tfsdk:"external_ip"`
+	Protocol     types.String `tfsdk:"protocol"`
+}
+
+var instancePortAttrTypes = map[string]attr.Type{
+	"internal_port": types.Int32Type,
+	"external_port": types.Int32Type,
+	"external_ip":   types.StringType,
+	"protocol":      types.StringType,
+}
+
+// getPortsForInstance lists the ports exposed by an instance. Unlike its
+// client-go counterpart it also keeps the protocol reported by the API, and
+// it keeps the scheme of the service API URL so that a plain http stand-in
+// for the OSC API works.
+func getPortsForInstance(ctx context.Context, osaasContext *oscClient, serviceId string, name string) ([]instancePort, error) {
+	service, err := getService(ctx, osaasContext, serviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceURL, err := url.Parse(service.ApiUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	portsURL := fmt.Sprintf("%s://%s/ports/%s", instanceURL.Scheme, instanceURL.Host, name)
+
+	var ports []instancePort
+	err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+		return createFetch(ctx, osaasContext.retry, portsURL, "GET", nil, &ports, auth{"x-jwt", fmt.Sprintf("Bearer %s", token)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
+func instancePortModels(ports []instancePort) []InstancePortModel {
+	models := []InstancePortModel{}
+	for _, port := range ports {
+		protocol := types.StringNull()
+		if port.Protocol != "" {
+			protocol = types.StringValue(port.Protocol)
+		}
+		models = append(models, InstancePortModel{
+			InternalPort: types.Int32Value(int32(port.InternalPort)),
+			ExternalPort: types.Int32Value(int32(port.ExternalPort)),
+			ExternalIp:   types.StringValue(port.ExternalIP),
+			Protocol:     protocol,
+		})
+	}
+	return models
+}
+
+// instancePortsValue converts the ports of an instance to the value of a
+// resource's computed ports attribute.
+func instancePortsValue(ctx context.Context, ports []instancePort) (types.List, diag.Diagnostics) {
+	return types.ListValueFrom(ctx, types.ObjectType{AttrTypes: instancePortAttrTypes}, instancePortModels(ports))
+}
+
+// parseImportId returns the instance name from an import identifier given
+// either as "<name>" or as "<service id>/<name>".
+func parseImportId(serviceId string, id string) (string, error) {
+	name := id
+	if prefix, rest, found := strings.Cut(id, "/"); found {
+		if prefix != serviceId {
+			return "", fmt.Errorf("expected service id %q, got %q", serviceId, prefix)
+		}
+		name = rest
+	}
+	if name == "" {
+		return "", fmt.Errorf("expected an import identifier of the form <name> or %s/<name>, got %q", serviceId, id)
+	}
+	return name, nil
+}
+
+// sensitiveParameterPattern matches the names the generator marks sensitive
+// through sensitivePatterns and sensitiveInclude in template/config.json.
+var sensitiveParameterPattern = regexp.MustCompile(`(?i)(pass|secret|token|key|^auth$)`)
+
+// isSensitiveParameter guesses from its name whether an instance parameter
+// holds a credential.
+func isSensitiveParameter(name string) bool {
+	return sensitiveParameterPattern.MatchString(name)
+}
+
+// instanceParameters returns the scalar parameters of an instance as strings,
+// split into those that do not and those that do hold a credential.
+func instanceParameters(instance map[string]interface{}) (map[string]types.String, map[string]types.String) {
+	parameters := map[string]types.String{}
+	sensitiveParameters := map[string]types.String{}
+	for name, value := range instance {
+		if name == "name" || name == "url" {
+			continue
+		}
+		target := parameters
+		if isSensitiveParameter(name) {
+			target = sensitiveParameters
+		}
+		switch v := value.(type) {
+		case string:
+			target[name] = types.StringValue(v)
+		case bool:
+			target[name] = types.StringValue(strconv.FormatBool(v))
+		case float64:
+			target[name] = types.StringValue(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return parameters, sensitiveParameters
+}
+
+// numberParameter converts a number attribute to a JSON number for the
+// parameters of a new instance.
+func numberParameter(value types.Number) json.Number {
+	return json.Number(value.ValueBigFloat().Text('g', -1))
+}
+
+// listParameter converts a list attribute to the values of an instance
+// parameter.
+func listParameter(value types.List) []string {
+	values := []string{}
+	for _, element := range value.Elements() {
+		if s, ok := element.(types.String); ok {
+			values = append(values, s.ValueString())
+		}
+	}
+	return values
+}
+
+// joinedListParameter converts a list attribute to a comma separated instance
+// parameter.
+func joinedListParameter(value types.List) string {
+	return strings.Join(listParameter(value), ",")
+}
+
+// listFromParameter converts an instance parameter given either as a JSON
+// array or as a comma separated string to a list attribute.
+func listFromParameter(value interface{}) (types.List, bool) {
+	var values []attr.Value
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return types.ListNull(types.StringType), false
+		}
+		for _, s := range strings.Split(v, ",") {
+			values = append(values, types.StringValue(strings.TrimSpace(s)))
+		}
+	case []interface{}:
+		for _, element := range v {
+			s, ok := element.(string)
+			if !ok {
+				return types.ListNull(types.StringType), false
+			}
+			values = append(values, types.StringValue(s))
+		}
+	default:
+		return types.ListNull(types.StringType), false
+	}
+	return types.ListValueMust(types.StringType, values), true
+}
+
+// instancePayload builds the parameters of a CreateInstance request from the
+// planned attribute values, keyed by parameter name. Null and unknown values
+// are left out so the service default applies. List parameters are sent as a
+// comma separated string, except those named in arrayLists which are sent as
+// a JSON array.
+func instancePayload(values map[string]attr.Value, arrayLists ...string) map[string]interface{} {
+	payload := map[string]interface{}{}
+	for name, value := range values {
+		if value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		switch v := value.(type) {
+		case types.String:
+			payload[name] = v.ValueString()
+		case types.Bool:
+			payload[name] = v.ValueBool()
+		case types.Int64:
+			payload[name] = v.ValueInt64()
+		case types.Number:
+			payload[name] = numberParameter(v)
+		case types.List:
+			if slices.Contains(arrayLists, name) {
+				payload[name] = listParameter(v)
+			} else {
+				payload[name] = joinedListParameter(v)
+			}
+		}
+	}
+	return payload
+}
+
+// int64FromParameter converts an instance parameter given either as a JSON
+// number or as a numeric string, e.g. for a parameter the catalog types as a
+// string, to an integer.
+func int64FromParameter(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// numberFromParameter converts an instance parameter given either as a JSON
+// number or as a numeric string to a number.
+func numberFromParameter(value interface{}) (*big.Float, bool) {
+	switch v := value.(type) {
+	case float64:
+		return big.NewFloat(v), true
+	case string:
+		f, _, err := big.ParseFloat(strings.TrimSpace(v), 10, 53, big.ToNearestEven)
+		return f, err == nil
+	}
+	return nil, false
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestInstancePayload(t *testing.T) {
+	languages := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("en"), types.StringValue("sv")})
+	payload := instancePayload(map[string]attr.Value{
+		"name":         types.StringValue("example"),
+		"Title":        types.StringNull(),
+		"Url":          types.StringUnknown(),
+		"Enabled":      types.BoolValue(false),
+		"Debug":        types.BoolNull(),
+		"Workers":      types.Int64Value(0),
+		"Port":         types.Int64Null(),
+		"Ratio":        types.NumberValue(big.NewFloat(0.5)),
+		"Scale":        types.NumberUnknown(),
+		"Languages":    languages,
+		"LanguageList": languages,
+		"Origins":      types.ListNull(types.StringType),
+	}, "LanguageList")
+
+	want := map[string]interface{}{
+		"name":         "example",
+		"Enabled":      false,
+		"Workers":      int64(0),
+		"Ratio":        json.Number("0.5"),
+		"Languages":    "en,sv",
+		"LanguageList": []string{"en", "sv"},
+	}
+	if !reflect.DeepEqual(payload, want) {
+		t.Errorf("got %#v, want %#v", payload, want)
+	}
+}
+
+func TestListFromParameter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+		ok    bool
+	}{
+		{"comma separated", "en, sv,fi", []string{"en", "sv", "fi"}, true},
+		{"single value", "en", []string{"en"}, true},
+		{"empty string", "", nil, false},
+		{"array", []interface{}{"en", "sv"}, []string{"en", "sv"}, true},
+		{"empty array", []interface{}{}, []string{}, true},
+		{"array of numbers", []interface{}{1.0}, nil, false},
+		{"number", 1.0, nil, false},
+		{"missing", nil, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := listFromParameter(test.value)
+			if ok != test.ok {
+				t.Fatalf("got ok %t, want %t", ok, test.ok)
+			}
+			if !ok {
+				if !got.IsNull() {
+					t.Errorf("expected a null list, got %s", got)
+				}
+				return
+			}
+			if values := listParameter(got); !reflect.DeepEqual(values, test.want) {
+				t.Errorf("got %q, want %q", values, test.want)
+			}
+		})
+	}
+}
+
+func TestInt64FromParameter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int64
+		ok    bool
+	}{
+		{"number", 8080.0, 8080, true},
+		{"fraction", 1.5, 0, false},
+		{"numeric string", " 42 ", 42, true},
+		{"negative string", "-1", -1, true},
+		{"decimal string", "1.5", 0, false},
+		{"empty string", "", 0, false},
+		{"text", "many", 0, false},
+		{"boolean", true, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := int64FromParameter(test.value)
+			if ok != test.ok || (ok && got != test.want) {
+				t.Errorf("got %d, %t, want %d, %t", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestNumberFromParameter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+		ok    bool
+	}{
+		{"number", 0.25, "0.25", true},
+		{"numeric string", "2.5", "2.5", true},
+		{"integer string", " 3 ", "3", true},
+		{"empty string", "", "", false},
+		{"text", "half", "", false},
+		{"boolean", false, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := numberFromParameter(test.value)
+			if ok != test.ok {
+				t.Fatalf("got ok %t, want %t", ok, test.ok)
+			}
+			if ok && got.Text('g', -1) != test.want {
+				t.Errorf("got %s, want %s", got.Text('g', -1), test.want)
+			}
+		})
+	}
+}
@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &ablindbergoscvmafstudio{}
 	_ resource.ResourceWithConfigure = &ablindbergoscvmafstudio{}
+	_ resource.ResourceWithImportState = &ablindbergoscvmafstudio{}
+	_ resource.ResourceWithUpgradeState = &ablindbergoscvmafstudio{}
 )
 
 func Newablindbergoscvmafstudio() resource.Resource {
@@ -28,7 +33,7 @@ func (r *ablindbergoscvmafstudio) Configure(ctx context.Context, req resource.Co
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *ablindbergoscvmafstudio) Configure(ctx context.Context, req resource.Co
 
 // ablindbergoscvmafstudio is the resource implementation.
 type ablindbergoscvmafstudio struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type ablindbergoscvmafstudioModel struct {
@@ -52,6 +57,10 @@ type ablindbergoscvmafstudioModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Oscaccesstoken         types.String       `tfsdk:"osc_access_token"`
 }
@@ -61,9 +70,11 @@ func (r *ablindbergoscvmafstudio) Metadata(_ context.Context, req resource.Metad
 }
 
 // Schema defines the schema for the resource.
-func (r *ablindbergoscvmafstudio) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *ablindbergoscvmafstudio) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Transform your video quality assessment with OSC VMAF Studio, a cloud-based tool leveraging OSC and Eyevinn EasyVMAF. Enjoy effortless S3 storage management, detailed VMAF analysis, and secure credentials.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Transform your video quality assessment with OSC VMAF Studio, a cloud-based tool leveraging OSC and Eyevinn EasyVMAF. Enjoy effortless S3 storage management, detailed VMAF analysis, and secure credentials.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -81,15 +92,63 @@ func (r *ablindbergoscvmafstudio) Schema(_ context.Context, _ resource.SchemaReq
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of osc-vmaf-studio",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"osc_access_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Personal Access Token for authenticating with OSC (Open Source Cloud) services, specifically required for accessing Eyevinn EasyVMAF service that performs the VMAF video quality analysis",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -102,24 +161,52 @@ func (r *ablindbergoscvmafstudio) Create(ctx context.Context, req resource.Creat
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("ablindberg-osc-vmaf-studio")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "ablindberg-osc-vmaf-studio"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "ablindberg-osc-vmaf-studio", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "ablindberg-osc-vmaf-studio", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"oscAccessToken": plan.Oscaccesstoken.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"oscAccessToken": plan.Oscaccesstoken,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "ablindberg-osc-vmaf-studio", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "ablindberg-osc-vmaf-studio", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "ablindberg-osc-vmaf-studio", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -134,10 +221,14 @@ func (r *ablindbergoscvmafstudio) Create(ctx context.Context, req resource.Creat
 
 	// Update the state with the actual data returned from the API
 	state := ablindbergoscvmafstudioModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("ablindberg-osc-vmaf-studio"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Oscaccesstoken: plan.Oscaccesstoken,
 	}
@@ -148,14 +239,142 @@ func (r *ablindbergoscvmafstudio) Create(ctx context.Context, req resource.Creat
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "ablindberg-osc-vmaf-studio", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *ablindbergoscvmafstudio) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ablindbergoscvmafstudioModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "ablindberg-osc-vmaf-studio"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"osc_access_token": state.Oscaccesstoken,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("ablindberg-osc-vmaf-studio")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *ablindbergoscvmafstudio) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *ablindbergoscvmafstudio) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("ablindberg-osc-vmaf-studio", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *ablindbergoscvmafstudio) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state ablindbergoscvmafstudioModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"osc_access_token",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -167,15 +386,30 @@ func (r *ablindbergoscvmafstudio) Delete(ctx context.Context, req resource.Delet
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("ablindberg-osc-vmaf-studio")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "ablindberg-osc-vmaf-studio"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "ablindberg-osc-vmaf-studio", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "ablindberg-osc-vmaf-studio", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "ablindberg-osc-vmaf-studio", state.Name.ValueString()))
 		return
 	}
 }
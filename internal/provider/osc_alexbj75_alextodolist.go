@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &alexbj75alextodolist{}
 	_ resource.ResourceWithConfigure = &alexbj75alextodolist{}
+	_ resource.ResourceWithImportState = &alexbj75alextodolist{}
+	_ resource.ResourceWithUpgradeState = &alexbj75alextodolist{}
 )
 
 func Newalexbj75alextodolist() resource.Resource {
@@ -28,7 +35,7 @@ func (r *alexbj75alextodolist) Configure(ctx context.Context, req resource.Confi
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *alexbj75alextodolist) Configure(ctx context.Context, req resource.Confi
 
 // alexbj75alextodolist is the resource implementation.
 type alexbj75alextodolist struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type alexbj75alextodolistModel struct {
@@ -52,9 +59,13 @@ type alexbj75alextodolistModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Dbhost         types.String       `tfsdk:"db_host"`
-	Dbport         types.String       `tfsdk:"db_port"`
+	Dbport         types.Int64       `tfsdk:"db_port"`
 	Dbuser         types.String       `tfsdk:"db_user"`
 	Dbpassword         types.String       `tfsdk:"db_password"`
 	Dbname         types.String       `tfsdk:"db_name"`
@@ -65,9 +76,11 @@ func (r *alexbj75alextodolist) Metadata(_ context.Context, req resource.Metadata
 }
 
 // Schema defines the schema for the resource.
-func (r *alexbj75alextodolist) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *alexbj75alextodolist) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Boost your productivity with our full-stack Todo List Application! Featuring a sleek UI, robust Node.js backend, and seamless MariaDB integration, it&#39;s the perfect tool for managing tasks effortlessly.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Boost your productivity with our full-stack Todo List Application! Featuring a sleek UI, robust Node.js backend, and seamless MariaDB integration, it's the perfect tool for managing tasks effortlessly.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -85,31 +98,94 @@ func (r *alexbj75alextodolist) Schema(_ context.Context, _ resource.SchemaReques
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of alextodolist",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"db_host": schema.StringAttribute{
 				Required: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"db_port": schema.StringAttribute{
+			"db_port": schema.Int64Attribute{
 				Required: true,
 				Description: "",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"db_user": schema.StringAttribute{
 				Required: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"db_password": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"db_name": schema.StringAttribute{
 				Required: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -122,28 +198,56 @@ func (r *alexbj75alextodolist) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("alexbj75-alextodolist")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "alexbj75-alextodolist", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"dbHost": plan.Dbhost.ValueString(),
-		"dbPort": plan.Dbport.ValueString(),
-		"dbUser": plan.Dbuser.ValueString(),
-		"dbPassword": plan.Dbpassword.ValueString(),
-		"dbName": plan.Dbname.ValueString(),
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-alextodolist"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-alextodolist", plan.Name.ValueString()))
+		return
+	}
+
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"dbHost": plan.Dbhost,
+		"dbPort": plan.Dbport,
+		"dbUser": plan.Dbuser,
+		"dbPassword": plan.Dbpassword,
+		"dbName": plan.Dbname,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "alexbj75-alextodolist", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "alexbj75-alextodolist", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "alexbj75-alextodolist", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "alexbj75-alextodolist", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "alexbj75-alextodolist", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -158,10 +262,14 @@ func (r *alexbj75alextodolist) Create(ctx context.Context, req resource.CreateRe
 
 	// Update the state with the actual data returned from the API
 	state := alexbj75alextodolistModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("alexbj75-alextodolist"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Dbhost: plan.Dbhost,
 		Dbport: plan.Dbport,
@@ -176,14 +284,162 @@ func (r *alexbj75alextodolist) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "alexbj75-alextodolist", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "alexbj75-alextodolist", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *alexbj75alextodolist) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state alexbj75alextodolistModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-alextodolist"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "alexbj75-alextodolist", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "alexbj75-alextodolist", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["dbHost"].(string); ok && value != "" && state.Dbhost.IsNull() {
+			state.Dbhost = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["dbPort"]); ok && state.Dbport.IsNull() {
+			state.Dbport = types.Int64Value(value)
+		}
+		if value, ok := instance["dbUser"].(string); ok && value != "" && state.Dbuser.IsNull() {
+			state.Dbuser = types.StringValue(value)
+		}
+		if value, ok := instance["dbName"].(string); ok && value != "" && state.Dbname.IsNull() {
+			state.Dbname = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"db_host": state.Dbhost,
+			"db_port": state.Dbport,
+			"db_user": state.Dbuser,
+			"db_password": state.Dbpassword,
+			"db_name": state.Dbname,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("alexbj75-alextodolist")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *alexbj75alextodolist) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *alexbj75alextodolist) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("alexbj75-alextodolist", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *alexbj75alextodolist) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state alexbj75alextodolistModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"db_host",
+		"db_port",
+		"db_user",
+		"db_password",
+		"db_name",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -195,15 +451,30 @@ func (r *alexbj75alextodolist) Delete(ctx context.Context, req resource.DeleteRe
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("alexbj75-alextodolist")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-alextodolist"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "alexbj75-alextodolist", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "alexbj75-alextodolist", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "alexbj75-alextodolist", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "alexbj75-alextodolist", state.Name.ValueString()))
 		return
 	}
 }
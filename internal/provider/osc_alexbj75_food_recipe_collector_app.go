@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &alexbj75foodrecipecollectorapp{}
 	_ resource.ResourceWithConfigure = &alexbj75foodrecipecollectorapp{}
+	_ resource.ResourceWithImportState = &alexbj75foodrecipecollectorapp{}
+	_ resource.ResourceWithUpgradeState = &alexbj75foodrecipecollectorapp{}
 )
 
 func Newalexbj75foodrecipecollectorapp() resource.Resource {
@@ -28,7 +33,7 @@ func (r *alexbj75foodrecipecollectorapp) Configure(ctx context.Context, req reso
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *alexbj75foodrecipecollectorapp) Configure(ctx context.Context, req reso
 
 // alexbj75foodrecipecollectorapp is the resource implementation.
 type alexbj75foodrecipecollectorapp struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type alexbj75foodrecipecollectorappModel struct {
@@ -52,8 +57,12 @@ type alexbj75foodrecipecollectorappModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
-	Alloworigin         bool       `tfsdk:"allow_origin"`
+	Alloworigin         types.Bool       `tfsdk:"allow_origin"`
 	Databaseurl         types.String       `tfsdk:"database_url"`
 }
 
@@ -62,9 +71,11 @@ func (r *alexbj75foodrecipecollectorapp) Metadata(_ context.Context, req resourc
 }
 
 // Schema defines the schema for the resource.
-func (r *alexbj75foodrecipecollectorapp) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *alexbj75foodrecipecollectorapp) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Effortlessly collect and organize all your favorite recipes with our powerful app. Paste any recipe URL, let our backend do the heavy lifting, and enjoy a unified, easy-to-navigate view. Delight in hassle-free culinary exploration today!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Effortlessly collect and organize all your favorite recipes with our powerful app. Paste any recipe URL, let our backend do the heavy lifting, and enjoy a unified, easy-to-navigate view. Delight in hassle-free culinary exploration today!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -82,19 +93,69 @@ func (r *alexbj75foodrecipecollectorapp) Schema(_ context.Context, _ resource.Sc
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of food-recipe-collector-app",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"allow_origin": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls Cross-Origin Resource Sharing (CORS) permissions for the API, determining which domains can make requests to the backend",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"database_url": schema.StringAttribute{
 				Required: true,
 				Description: "Complete database connection URL containing all necessary connection parameters for the MariaDB instance where recipes are stored",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -107,25 +168,53 @@ func (r *alexbj75foodrecipecollectorapp) Create(ctx context.Context, req resourc
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("alexbj75-food-recipe-collector-app")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-food-recipe-collector-app"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-food-recipe-collector-app", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "alexbj75-food-recipe-collector-app", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
 		"allowOrigin": plan.Alloworigin,
-		"databaseUrl": plan.Databaseurl.ValueString(),
+		"databaseUrl": plan.Databaseurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "alexbj75-food-recipe-collector-app", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "alexbj75-food-recipe-collector-app", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "alexbj75-food-recipe-collector-app", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -140,10 +229,14 @@ func (r *alexbj75foodrecipecollectorapp) Create(ctx context.Context, req resourc
 
 	// Update the state with the actual data returned from the API
 	state := alexbj75foodrecipecollectorappModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("alexbj75-food-recipe-collector-app"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Alloworigin: plan.Alloworigin,
 		Databaseurl: plan.Databaseurl,
@@ -155,14 +248,150 @@ func (r *alexbj75foodrecipecollectorapp) Create(ctx context.Context, req resourc
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "alexbj75-food-recipe-collector-app", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *alexbj75foodrecipecollectorapp) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state alexbj75foodrecipecollectorappModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-food-recipe-collector-app"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["allowOrigin"].(bool); ok && state.Alloworigin.IsNull() {
+			state.Alloworigin = types.BoolValue(value)
+		}
+		if value, ok := instance["databaseUrl"].(string); ok && value != "" && state.Databaseurl.IsNull() {
+			state.Databaseurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"allow_origin": state.Alloworigin,
+			"database_url": state.Databaseurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("alexbj75-food-recipe-collector-app")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *alexbj75foodrecipecollectorapp) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *alexbj75foodrecipecollectorapp) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("alexbj75-food-recipe-collector-app", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *alexbj75foodrecipecollectorapp) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state alexbj75foodrecipecollectorappModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"allow_origin",
+		"database_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -174,15 +403,30 @@ func (r *alexbj75foodrecipecollectorapp) Delete(ctx context.Context, req resourc
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("alexbj75-food-recipe-collector-app")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "alexbj75-food-recipe-collector-app"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "alexbj75-food-recipe-collector-app", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "alexbj75-food-recipe-collector-app", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "alexbj75-food-recipe-collector-app", state.Name.ValueString()))
 		return
 	}
 }
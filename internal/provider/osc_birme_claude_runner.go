@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &birmeclauderunner{}
 	_ resource.ResourceWithConfigure = &birmeclauderunner{}
+	_ resource.ResourceWithImportState = &birmeclauderunner{}
+	_ resource.ResourceWithUpgradeState = &birmeclauderunner{}
 )
 
 func Newbirmeclauderunner() resource.Resource {
@@ -28,7 +35,7 @@ func (r *birmeclauderunner) Configure(ctx context.Context, req resource.Configur
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *birmeclauderunner) Configure(ctx context.Context, req resource.Configur
 
 // birmeclauderunner is the resource implementation.
 type birmeclauderunner struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type birmeclauderunnerModel struct {
@@ -52,6 +59,10 @@ type birmeclauderunnerModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Prompt         types.String       `tfsdk:"prompt"`
 	Anthropicapikey         types.String       `tfsdk:"anthropic_api_key"`
@@ -59,9 +70,9 @@ type birmeclauderunnerModel struct {
 	Sourceurl         types.String       `tfsdk:"source_url"`
 	Gittoken         types.String       `tfsdk:"git_token"`
 	Model         types.String       `tfsdk:"model"`
-	Maxturns         types.String       `tfsdk:"max_turns"`
-	Allowedtools         types.String       `tfsdk:"allowed_tools"`
-	Disallowedtools         types.String       `tfsdk:"disallowed_tools"`
+	Maxturns         types.Int64       `tfsdk:"max_turns"`
+	Allowedtools         types.List       `tfsdk:"allowed_tools"`
+	Disallowedtools         types.List       `tfsdk:"disallowed_tools"`
 	Subpath         types.String       `tfsdk:"sub_path"`
 	Oscaccesstoken         types.String       `tfsdk:"osc_access_token"`
 	Configsvc         types.String       `tfsdk:"config_svc"`
@@ -74,10 +85,11 @@ func (r *birmeclauderunner) Metadata(_ context.Context, req resource.MetadataReq
 }
 
 // Schema defines the schema for the resource.
-func (r *birmeclauderunner) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *birmeclauderunner) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Streamline your AI-driven operations with Claude Runner. Effortlessly execute AI tasks in a container, pulling directly from your Git repository. Simplify agent workflows, automate code analysis, and boost productivity seamlessly. Perfect for dynamic environments requiring flexibility and precision.
-`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Streamline your AI-driven operations with Claude Runner. Effortlessly execute AI tasks in a container, pulling directly from your Git repository. Simplify agent workflows, automate code analysis, and boost productivity seamlessly. Perfect for dynamic environments requiring flexibility and precision.\n",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -95,67 +107,163 @@ func (r *birmeclauderunner) Schema(_ context.Context, _ resource.SchemaRequest,
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of claude-runner",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"prompt": schema.StringAttribute{
 				Required: true,
 				Description: "The task or prompt for Claude to execute within the cloned repository",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"anthropic_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Anthropic API key for Claude authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"claude_code_oauth_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Claude OAuth token as an alternative authentication method to the Anthropic API key",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"source_url": schema.StringAttribute{
 				Required: true,
 				Description: "Git repository URL to clone containing the Claude Code configuration and source code",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"git_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Token for cloning private repositories, supporting GitHub Personal Access Tokens and Gitea-style tokens",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"model": schema.StringAttribute{
 				Optional: true,
 				Description: "Specifies which Claude model to use for the execution",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"max_turns": schema.StringAttribute{
+			"max_turns": schema.Int64Attribute{
 				Optional: true,
 				Description: "Maximum number of agentic turns Claude can perform during task execution",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
-			"allowed_tools": schema.StringAttribute{
+			"allowed_tools": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma-separated list of tools that Claude is allowed to use during execution",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
-			"disallowed_tools": schema.StringAttribute{
+			"disallowed_tools": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma-separated list of tools that Claude is not allowed to use during execution",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
 			"sub_path": schema.StringAttribute{
 				Optional: true,
 				Description: "Subdirectory within the cloned repository to use as the working directory",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"osc_access_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Open Source Cloud access token that configures an MCP server for OSC integration",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"config_svc": schema.StringAttribute{
 				Optional: true,
 				Description: "Name of an OSC Application Config Service instance for loading environment variables",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"config_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "API key for encrypted parameter store to decrypt secret parameters",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"osc_mcp_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Override URL for the OSC MCP server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -168,37 +276,65 @@ func (r *birmeclauderunner) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("birme-claude-runner")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-claude-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-claude-runner", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "birme-claude-runner", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"Prompt": plan.Prompt.ValueString(),
-		"AnthropicApiKey": plan.Anthropicapikey.ValueString(),
-		"ClaudeCodeOauthToken": plan.Claudecodeoauthtoken.ValueString(),
-		"SourceUrl": plan.Sourceurl.ValueString(),
-		"GitToken": plan.Gittoken.ValueString(),
-		"Model": plan.Model.ValueString(),
-		"MaxTurns": plan.Maxturns.ValueString(),
-		"AllowedTools": plan.Allowedtools.ValueString(),
-		"DisallowedTools": plan.Disallowedtools.ValueString(),
-		"SubPath": plan.Subpath.ValueString(),
-		"OscAccessToken": plan.Oscaccesstoken.ValueString(),
-		"ConfigSvc": plan.Configsvc.ValueString(),
-		"ConfigApiKey": plan.Configapikey.ValueString(),
-		"OscMcpUrl": plan.Oscmcpurl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"Prompt": plan.Prompt,
+		"AnthropicApiKey": plan.Anthropicapikey,
+		"ClaudeCodeOauthToken": plan.Claudecodeoauthtoken,
+		"SourceUrl": plan.Sourceurl,
+		"GitToken": plan.Gittoken,
+		"Model": plan.Model,
+		"MaxTurns": plan.Maxturns,
+		"AllowedTools": plan.Allowedtools,
+		"DisallowedTools": plan.Disallowedtools,
+		"SubPath": plan.Subpath,
+		"OscAccessToken": plan.Oscaccesstoken,
+		"ConfigSvc": plan.Configsvc,
+		"ConfigApiKey": plan.Configapikey,
+		"OscMcpUrl": plan.Oscmcpurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "birme-claude-runner", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "birme-claude-runner", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "birme-claude-runner", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "birme-claude-runner", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "birme-claude-runner", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -213,10 +349,14 @@ func (r *birmeclauderunner) Create(ctx context.Context, req resource.CreateReque
 
 	// Update the state with the actual data returned from the API
 	state := birmeclauderunnerModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("birme-claude-runner"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Prompt: plan.Prompt,
 		Anthropicapikey: plan.Anthropicapikey,
@@ -240,14 +380,195 @@ func (r *birmeclauderunner) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "birme-claude-runner", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "birme-claude-runner", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *birmeclauderunner) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state birmeclauderunnerModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-claude-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "birme-claude-runner", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "birme-claude-runner", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["Prompt"].(string); ok && value != "" && state.Prompt.IsNull() {
+			state.Prompt = types.StringValue(value)
+		}
+		if value, ok := instance["SourceUrl"].(string); ok && value != "" && state.Sourceurl.IsNull() {
+			state.Sourceurl = types.StringValue(value)
+		}
+		if value, ok := instance["Model"].(string); ok && value != "" && state.Model.IsNull() {
+			state.Model = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["MaxTurns"]); ok && state.Maxturns.IsNull() {
+			state.Maxturns = types.Int64Value(value)
+		}
+		if value, ok := listFromParameter(instance["AllowedTools"]); ok && state.Allowedtools.IsNull() {
+			state.Allowedtools = value
+		}
+		if value, ok := listFromParameter(instance["DisallowedTools"]); ok && state.Disallowedtools.IsNull() {
+			state.Disallowedtools = value
+		}
+		if value, ok := instance["SubPath"].(string); ok && value != "" && state.Subpath.IsNull() {
+			state.Subpath = types.StringValue(value)
+		}
+		if value, ok := instance["ConfigSvc"].(string); ok && value != "" && state.Configsvc.IsNull() {
+			state.Configsvc = types.StringValue(value)
+		}
+		if value, ok := instance["OscMcpUrl"].(string); ok && value != "" && state.Oscmcpurl.IsNull() {
+			state.Oscmcpurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"prompt": state.Prompt,
+			"anthropic_api_key": state.Anthropicapikey,
+			"claude_code_oauth_token": state.Claudecodeoauthtoken,
+			"source_url": state.Sourceurl,
+			"git_token": state.Gittoken,
+			"model": state.Model,
+			"max_turns": state.Maxturns,
+			"allowed_tools": state.Allowedtools,
+			"disallowed_tools": state.Disallowedtools,
+			"sub_path": state.Subpath,
+			"osc_access_token": state.Oscaccesstoken,
+			"config_svc": state.Configsvc,
+			"config_api_key": state.Configapikey,
+			"osc_mcp_url": state.Oscmcpurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("birme-claude-runner")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *birmeclauderunner) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *birmeclauderunner) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("birme-claude-runner", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *birmeclauderunner) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state birmeclauderunnerModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"prompt",
+		"anthropic_api_key",
+		"claude_code_oauth_token",
+		"source_url",
+		"git_token",
+		"model",
+		"max_turns",
+		"allowed_tools",
+		"disallowed_tools",
+		"sub_path",
+		"osc_access_token",
+		"config_svc",
+		"config_api_key",
+		"osc_mcp_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -259,15 +580,30 @@ func (r *birmeclauderunner) Delete(ctx context.Context, req resource.DeleteReque
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("birme-claude-runner")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-claude-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "birme-claude-runner", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "birme-claude-runner", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "birme-claude-runner", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "birme-claude-runner", state.Name.ValueString()))
 		return
 	}
 }
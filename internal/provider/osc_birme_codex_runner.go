@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &birmecodexrunner{}
 	_ resource.ResourceWithConfigure = &birmecodexrunner{}
+	_ resource.ResourceWithImportState = &birmecodexrunner{}
+	_ resource.ResourceWithUpgradeState = &birmecodexrunner{}
 )
 
 func Newbirmecodexrunner() resource.Resource {
@@ -28,7 +35,7 @@ func (r *birmecodexrunner) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *birmecodexrunner) Configure(ctx context.Context, req resource.Configure
 
 // birmecodexrunner is the resource implementation.
 type birmecodexrunner struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type birmecodexrunnerModel struct {
@@ -52,6 +59,10 @@ type birmecodexrunnerModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Prompt         types.String       `tfsdk:"prompt"`
 	Codexapikey         types.String       `tfsdk:"codex_api_key"`
@@ -59,9 +70,9 @@ type birmecodexrunnerModel struct {
 	Sourceurl         types.String       `tfsdk:"source_url"`
 	Gittoken         types.String       `tfsdk:"git_token"`
 	Model         types.String       `tfsdk:"model"`
-	Maxturns         types.String       `tfsdk:"max_turns"`
-	Allowedtools         types.String       `tfsdk:"allowed_tools"`
-	Disallowedtools         types.String       `tfsdk:"disallowed_tools"`
+	Maxturns         types.Int64       `tfsdk:"max_turns"`
+	Allowedtools         types.List       `tfsdk:"allowed_tools"`
+	Disallowedtools         types.List       `tfsdk:"disallowed_tools"`
 	Subpath         types.String       `tfsdk:"sub_path"`
 	Oscaccesstoken         types.String       `tfsdk:"osc_access_token"`
 	Configsvc         types.String       `tfsdk:"config_svc"`
@@ -73,9 +84,11 @@ func (r *birmecodexrunner) Metadata(_ context.Context, req resource.MetadataRequ
 }
 
 // Schema defines the schema for the resource.
-func (r *birmecodexrunner) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *birmecodexrunner) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Effortlessly automate your software with Codex Runner! Seamlessly integrate OpenAI Codex in a container to execute tasks on your Git repositories. Simplify your workflows and boost productivity today!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Effortlessly automate your software with Codex Runner! Seamlessly integrate OpenAI Codex in a container to execute tasks on your Git repositories. Simplify your workflows and boost productivity today!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -93,63 +106,156 @@ func (r *birmecodexrunner) Schema(_ context.Context, _ resource.SchemaRequest, r
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of codex-runner",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"prompt": schema.StringAttribute{
 				Required: true,
 				Description: "The task or prompt for Codex to execute on the cloned repository",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"codex_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "OpenAI API key for authenticating with Codex services",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"openai_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "OpenAI API key (alias for CODEX_API_KEY, gets normalized internally)",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"source_url": schema.StringAttribute{
 				Required: true,
 				Description: "Git repository URL to clone and work with",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"git_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Authentication token for cloning private repositories",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"model": schema.StringAttribute{
 				Optional: true,
 				Description: "AI model to use for the Codex session",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"max_turns": schema.StringAttribute{
+			"max_turns": schema.Int64Attribute{
 				Optional: true,
 				Description: "Maximum number of conversation turns or iterations for the Codex session",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
-			"allowed_tools": schema.StringAttribute{
+			"allowed_tools": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma-separated list of tools that Codex is permitted to use during execution",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
-			"disallowed_tools": schema.StringAttribute{
+			"disallowed_tools": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma-separated list of tools that Codex is prohibited from using during execution",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
 			"sub_path": schema.StringAttribute{
 				Optional: true,
 				Description: "Subdirectory within the cloned repository to use as the working directory",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"osc_access_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Open Source Cloud access token for enabling OSC MCP server and config service integration",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"config_svc": schema.StringAttribute{
 				Optional: true,
 				Description: "Name of an OSC Application Config Service instance for loading additional environment variables",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"config_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "API key for accessing encrypted parameters in the parameter store",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -162,36 +268,64 @@ func (r *birmecodexrunner) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("birme-codex-runner")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-codex-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-codex-runner", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "birme-codex-runner", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"Prompt": plan.Prompt.ValueString(),
-		"CodexApiKey": plan.Codexapikey.ValueString(),
-		"OpenaiApiKey": plan.Openaiapikey.ValueString(),
-		"SourceUrl": plan.Sourceurl.ValueString(),
-		"GitToken": plan.Gittoken.ValueString(),
-		"Model": plan.Model.ValueString(),
-		"MaxTurns": plan.Maxturns.ValueString(),
-		"AllowedTools": plan.Allowedtools.ValueString(),
-		"DisallowedTools": plan.Disallowedtools.ValueString(),
-		"SubPath": plan.Subpath.ValueString(),
-		"OscAccessToken": plan.Oscaccesstoken.ValueString(),
-		"ConfigSvc": plan.Configsvc.ValueString(),
-		"ConfigApiKey": plan.Configapikey.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"Prompt": plan.Prompt,
+		"CodexApiKey": plan.Codexapikey,
+		"OpenaiApiKey": plan.Openaiapikey,
+		"SourceUrl": plan.Sourceurl,
+		"GitToken": plan.Gittoken,
+		"Model": plan.Model,
+		"MaxTurns": plan.Maxturns,
+		"AllowedTools": plan.Allowedtools,
+		"DisallowedTools": plan.Disallowedtools,
+		"SubPath": plan.Subpath,
+		"OscAccessToken": plan.Oscaccesstoken,
+		"ConfigSvc": plan.Configsvc,
+		"ConfigApiKey": plan.Configapikey,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "birme-codex-runner", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "birme-codex-runner", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "birme-codex-runner", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "birme-codex-runner", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "birme-codex-runner", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -206,10 +340,14 @@ func (r *birmecodexrunner) Create(ctx context.Context, req resource.CreateReques
 
 	// Update the state with the actual data returned from the API
 	state := birmecodexrunnerModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("birme-codex-runner"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Prompt: plan.Prompt,
 		Codexapikey: plan.Codexapikey,
@@ -232,14 +370,190 @@ func (r *birmecodexrunner) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "birme-codex-runner", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "birme-codex-runner", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *birmecodexrunner) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state birmecodexrunnerModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-codex-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "birme-codex-runner", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "birme-codex-runner", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["Prompt"].(string); ok && value != "" && state.Prompt.IsNull() {
+			state.Prompt = types.StringValue(value)
+		}
+		if value, ok := instance["SourceUrl"].(string); ok && value != "" && state.Sourceurl.IsNull() {
+			state.Sourceurl = types.StringValue(value)
+		}
+		if value, ok := instance["Model"].(string); ok && value != "" && state.Model.IsNull() {
+			state.Model = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["MaxTurns"]); ok && state.Maxturns.IsNull() {
+			state.Maxturns = types.Int64Value(value)
+		}
+		if value, ok := listFromParameter(instance["AllowedTools"]); ok && state.Allowedtools.IsNull() {
+			state.Allowedtools = value
+		}
+		if value, ok := listFromParameter(instance["DisallowedTools"]); ok && state.Disallowedtools.IsNull() {
+			state.Disallowedtools = value
+		}
+		if value, ok := instance["SubPath"].(string); ok && value != "" && state.Subpath.IsNull() {
+			state.Subpath = types.StringValue(value)
+		}
+		if value, ok := instance["ConfigSvc"].(string); ok && value != "" && state.Configsvc.IsNull() {
+			state.Configsvc = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"prompt": state.Prompt,
+			"codex_api_key": state.Codexapikey,
+			"openai_api_key": state.Openaiapikey,
+			"source_url": state.Sourceurl,
+			"git_token": state.Gittoken,
+			"model": state.Model,
+			"max_turns": state.Maxturns,
+			"allowed_tools": state.Allowedtools,
+			"disallowed_tools": state.Disallowedtools,
+			"sub_path": state.Subpath,
+			"osc_access_token": state.Oscaccesstoken,
+			"config_svc": state.Configsvc,
+			"config_api_key": state.Configapikey,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("birme-codex-runner")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *birmecodexrunner) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *birmecodexrunner) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("birme-codex-runner", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *birmecodexrunner) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state birmecodexrunnerModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"prompt",
+		"codex_api_key",
+		"openai_api_key",
+		"source_url",
+		"git_token",
+		"model",
+		"max_turns",
+		"allowed_tools",
+		"disallowed_tools",
+		"sub_path",
+		"osc_access_token",
+		"config_svc",
+		"config_api_key",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -251,15 +565,30 @@ func (r *birmecodexrunner) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("birme-codex-runner")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "birme-codex-runner"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "birme-codex-runner", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "birme-codex-runner", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "birme-codex-runner", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "birme-codex-runner", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &centrifugalcentrifugo{}
 	_ resource.ResourceWithConfigure = &centrifugalcentrifugo{}
+	_ resource.ResourceWithImportState = &centrifugalcentrifugo{}
+	_ resource.ResourceWithUpgradeState = &centrifugalcentrifugo{}
 )
 
 func Newcentrifugalcentrifugo() resource.Resource {
@@ -28,7 +33,7 @@ func (r *centrifugalcentrifugo) Configure(ctx context.Context, req resource.Conf
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *centrifugalcentrifugo) Configure(ctx context.Context, req resource.Conf
 
 // centrifugalcentrifugo is the resource implementation.
 type centrifugalcentrifugo struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type centrifugalcentrifugoModel struct {
@@ -52,6 +57,10 @@ type centrifugalcentrifugoModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Tokenhmacsecretkey         types.String       `tfsdk:"token_hmac_secret_key"`
 	Adminpassword         types.String       `tfsdk:"admin_password"`
@@ -64,9 +73,11 @@ func (r *centrifugalcentrifugo) Metadata(_ context.Context, req resource.Metadat
 }
 
 // Schema defines the schema for the resource.
-func (r *centrifugalcentrifugo) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *centrifugalcentrifugo) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Boost your app&#39;s real-time capabilities with Centrifugo, an open-source messaging server supporting WebSocket, HTTP-streaming, and more. Scale effortlessly, integrate with any backend, and enhance user engagement today!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Boost your app's real-time capabilities with Centrifugo, an open-source messaging server supporting WebSocket, HTTP-streaming, and more. Scale effortlessly, integrate with any backend, and enhance user engagement today!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -84,27 +95,86 @@ func (r *centrifugalcentrifugo) Schema(_ context.Context, _ resource.SchemaReque
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of centrifugo",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"token_hmac_secret_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Secret key used for HMAC signing of JWT tokens for connection authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"admin_password": schema.StringAttribute{
 				Required: true,
-				Description: "Password required to access Centrifugo&#39;s embedded admin web UI",
+				Sensitive: true,
+				Description: "Password required to access Centrifugo's embedded admin web UI",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"api_key": schema.StringAttribute{
 				Optional: true,
-				Description: "Authentication key for accessing Centrifugo&#39;s HTTP and GRPC server API",
+				Sensitive: true,
+				Description: "Authentication key for accessing Centrifugo's HTTP and GRPC server API",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Connection URL for Redis server used for built-in scalability and message brokering",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -117,27 +187,55 @@ func (r *centrifugalcentrifugo) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("centrifugal-centrifugo")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "centrifugal-centrifugo"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "centrifugal-centrifugo", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "centrifugal-centrifugo", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"TokenHmacSecretKey": plan.Tokenhmacsecretkey.ValueString(),
-		"AdminPassword": plan.Adminpassword.ValueString(),
-		"ApiKey": plan.Apikey.ValueString(),
-		"RedisUrl": plan.Redisurl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"TokenHmacSecretKey": plan.Tokenhmacsecretkey,
+		"AdminPassword": plan.Adminpassword,
+		"ApiKey": plan.Apikey,
+		"RedisUrl": plan.Redisurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "centrifugal-centrifugo", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "centrifugal-centrifugo", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "centrifugal-centrifugo", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "centrifugal-centrifugo", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "centrifugal-centrifugo", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -152,10 +250,14 @@ func (r *centrifugalcentrifugo) Create(ctx context.Context, req resource.CreateR
 
 	// Update the state with the actual data returned from the API
 	state := centrifugalcentrifugoModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("centrifugal-centrifugo"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Tokenhmacsecretkey: plan.Tokenhmacsecretkey,
 		Adminpassword: plan.Adminpassword,
@@ -169,14 +271,151 @@ func (r *centrifugalcentrifugo) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "centrifugal-centrifugo", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "centrifugal-centrifugo", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *centrifugalcentrifugo) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state centrifugalcentrifugoModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "centrifugal-centrifugo"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "centrifugal-centrifugo", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "centrifugal-centrifugo", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["RedisUrl"].(string); ok && value != "" && state.Redisurl.IsNull() {
+			state.Redisurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"token_hmac_secret_key": state.Tokenhmacsecretkey,
+			"admin_password": state.Adminpassword,
+			"api_key": state.Apikey,
+			"redis_url": state.Redisurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("centrifugal-centrifugo")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *centrifugalcentrifugo) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *centrifugalcentrifugo) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("centrifugal-centrifugo", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *centrifugalcentrifugo) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state centrifugalcentrifugoModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"token_hmac_secret_key",
+		"admin_password",
+		"api_key",
+		"redis_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -188,15 +427,30 @@ func (r *centrifugalcentrifugo) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("centrifugal-centrifugo")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "centrifugal-centrifugo"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "centrifugal-centrifugo", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "centrifugal-centrifugo", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "centrifugal-centrifugo", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "centrifugal-centrifugo", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &channelengine{}
 	_ resource.ResourceWithConfigure = &channelengine{}
+	_ resource.ResourceWithImportState = &channelengine{}
+	_ resource.ResourceWithUpgradeState = &channelengine{}
 )
 
 func Newchannelengine() resource.Resource {
@@ -28,7 +35,7 @@ func (r *channelengine) Configure(ctx context.Context, req resource.ConfigureReq
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *channelengine) Configure(ctx context.Context, req resource.ConfigureReq
 
 // channelengine is the resource implementation.
 type channelengine struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type channelengineModel struct {
@@ -52,17 +59,21 @@ type channelengineModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Type         types.String       `tfsdk:"type"`
 	Url         types.String       `tfsdk:"url"`
-	Optsusedemuxedaudio         bool       `tfsdk:"optsuse_demuxed_audio"`
-	Optsusevttsubtitles         bool       `tfsdk:"optsuse_vtt_subtitles"`
+	Optsusedemuxedaudio         types.Bool       `tfsdk:"optsuse_demuxed_audio"`
+	Optsusevttsubtitles         types.Bool       `tfsdk:"optsuse_vtt_subtitles"`
 	Optsdefaultslateuri         types.String       `tfsdk:"optsdefault_slate_uri"`
-	Optslanglist         string       `tfsdk:"optslang_list"`
-	Optslanglistsubs         string       `tfsdk:"optslang_list_subs"`
+	Optslanglist         types.List       `tfsdk:"optslang_list"`
+	Optslanglistsubs         types.List       `tfsdk:"optslang_list_subs"`
 	Optspreset         types.String       `tfsdk:"optspreset"`
 	Optsprerollurl         types.String       `tfsdk:"optsprerollurl"`
-	Optsprerollduration         types.String       `tfsdk:"optsprerollduration"`
+	Optsprerollduration         types.Int64       `tfsdk:"optsprerollduration"`
 	Optswebhookapikey         types.String       `tfsdk:"optswebhookapikey"`
 }
 
@@ -71,9 +82,11 @@ func (r *channelengine) Metadata(_ context.Context, req resource.MetadataRequest
 }
 
 // Schema defines the schema for the resource.
-func (r *channelengine) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *channelengine) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Based on VOD2Live Technology you can generate a numerous amounts of FAST channels with a fraction of energy consumption compared to live transcoded FAST channels`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Based on VOD2Live Technology you can generate a numerous amounts of FAST channels with a fraction of energy consumption compared to live transcoded FAST channels",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -91,55 +104,138 @@ func (r *channelengine) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Enter channel name",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"type": schema.StringAttribute{
 				Required: true,
 				Description: "Plugin type",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"url": schema.StringAttribute{
 				Required: true,
 				Description: "URL of VOD, playlist to loop or WebHook",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"optsuse_demuxed_audio": schema.BoolAttribute{
 				Optional: true,
 				Description: "Use demuxed audio",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"optsuse_vtt_subtitles": schema.BoolAttribute{
 				Optional: true,
 				Description: "Use VTT subtitles",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"optsdefault_slate_uri": schema.StringAttribute{
 				Optional: true,
 				Description: "URI to default slate",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"optslang_list": schema.StringAttribute{
+			"optslang_list": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma separated list of languages",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
-			"optslang_list_subs": schema.StringAttribute{
+			"optslang_list_subs": schema.ListAttribute{
+				ElementType: types.StringType,
 				Optional: true,
 				Description: "Comma separated list of subtitle languages",
+				PlanModifiers: []planmodifier.List{
+					requiresReplaceList(),
+				},
 			},
 			"optspreset": schema.StringAttribute{
 				Optional: true,
 				Description: "Channel preset",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"optsprerollurl": schema.StringAttribute{
 				Optional: true,
 				Description: "URL to preroll",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"optsprerollduration": schema.StringAttribute{
+			"optsprerollduration": schema.Int64Attribute{
 				Optional: true,
 				Description: "Duration of preroll in milliseconds",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"optswebhookapikey": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "WebHook api key",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -152,34 +248,62 @@ func (r *channelengine) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("channel-engine")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "channel-engine"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "channel-engine", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "channel-engine", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
 		"type": plan.Type,
-		"url": plan.Url.ValueString(),
+		"url": plan.Url,
 		"opts.useDemuxedAudio": plan.Optsusedemuxedaudio,
 		"opts.useVttSubtitles": plan.Optsusevttsubtitles,
-		"opts.defaultSlateUri": plan.Optsdefaultslateuri.ValueString(),
+		"opts.defaultSlateUri": plan.Optsdefaultslateuri,
 		"opts.langList": plan.Optslanglist,
 		"opts.langListSubs": plan.Optslanglistsubs,
 		"opts.preset": plan.Optspreset,
-		"opts.preroll.url": plan.Optsprerollurl.ValueString(),
-		"opts.preroll.duration": plan.Optsprerollduration.ValueString(),
-		"opts.webhook.apikey": plan.Optswebhookapikey.ValueString(),
-	})
+		"opts.preroll.url": plan.Optsprerollurl,
+		"opts.preroll.duration": plan.Optsprerollduration,
+		"opts.webhook.apikey": plan.Optswebhookapikey,
+	}, "opts.langList", "opts.langListSubs")
+
+	instance, err := createInstance(ctx, r.osaasContext, "channel-engine", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "channel-engine", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "channel-engine", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "channel-engine", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "channel-engine", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -194,10 +318,14 @@ func (r *channelengine) Create(ctx context.Context, req resource.CreateRequest,
 
 	// Update the state with the actual data returned from the API
 	state := channelengineModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("channel-engine"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Type: plan.Type,
 		Url: plan.Url,
@@ -218,14 +346,192 @@ func (r *channelengine) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "channel-engine", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "channel-engine", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *channelengine) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state channelengineModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "channel-engine"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "channel-engine", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "channel-engine", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "channel-engine", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "channel-engine", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "channel-engine", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["type"].(string); ok && value != "" && state.Type.IsNull() {
+			state.Type = types.StringValue(value)
+		}
+		if value, ok := instance["url"].(string); ok && value != "" && state.Url.IsNull() {
+			state.Url = types.StringValue(value)
+		}
+		if value, ok := instance["opts.useDemuxedAudio"].(bool); ok && state.Optsusedemuxedaudio.IsNull() {
+			state.Optsusedemuxedaudio = types.BoolValue(value)
+		}
+		if value, ok := instance["opts.useVttSubtitles"].(bool); ok && state.Optsusevttsubtitles.IsNull() {
+			state.Optsusevttsubtitles = types.BoolValue(value)
+		}
+		if value, ok := instance["opts.defaultSlateUri"].(string); ok && value != "" && state.Optsdefaultslateuri.IsNull() {
+			state.Optsdefaultslateuri = types.StringValue(value)
+		}
+		if value, ok := listFromParameter(instance["opts.langList"]); ok && state.Optslanglist.IsNull() {
+			state.Optslanglist = value
+		}
+		if value, ok := listFromParameter(instance["opts.langListSubs"]); ok && state.Optslanglistsubs.IsNull() {
+			state.Optslanglistsubs = value
+		}
+		if value, ok := instance["opts.preset"].(string); ok && value != "" && state.Optspreset.IsNull() {
+			state.Optspreset = types.StringValue(value)
+		}
+		if value, ok := instance["opts.preroll.url"].(string); ok && value != "" && state.Optsprerollurl.IsNull() {
+			state.Optsprerollurl = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["opts.preroll.duration"]); ok && state.Optsprerollduration.IsNull() {
+			state.Optsprerollduration = types.Int64Value(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"type": state.Type,
+			"url": state.Url,
+			"optsuse_demuxed_audio": state.Optsusedemuxedaudio,
+			"optsuse_vtt_subtitles": state.Optsusevttsubtitles,
+			"optsdefault_slate_uri": state.Optsdefaultslateuri,
+			"optslang_list": state.Optslanglist,
+			"optslang_list_subs": state.Optslanglistsubs,
+			"optspreset": state.Optspreset,
+			"optsprerollurl": state.Optsprerollurl,
+			"optsprerollduration": state.Optsprerollduration,
+			"optswebhookapikey": state.Optswebhookapikey,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("channel-engine")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *channelengine) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *channelengine) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("channel-engine", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *channelengine) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state channelengineModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"type",
+		"url",
+		"optsuse_demuxed_audio",
+		"optsuse_vtt_subtitles",
+		"optsdefault_slate_uri",
+		"optslang_list",
+		"optslang_list_subs",
+		"optspreset",
+		"optsprerollurl",
+		"optsprerollduration",
+		"optswebhookapikey",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -237,15 +543,30 @@ func (r *channelengine) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("channel-engine")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "channel-engine"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "channel-engine", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "channel-engine", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "channel-engine", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "channel-engine", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "channel-engine", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "channel-engine", state.Name.ValueString()))
 		return
 	}
 }
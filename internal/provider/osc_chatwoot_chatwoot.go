@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &chatwootchatwoot{}
 	_ resource.ResourceWithConfigure = &chatwootchatwoot{}
+	_ resource.ResourceWithImportState = &chatwootchatwoot{}
+	_ resource.ResourceWithUpgradeState = &chatwootchatwoot{}
 )
 
 func Newchatwootchatwoot() resource.Resource {
@@ -28,7 +35,7 @@ func (r *chatwootchatwoot) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *chatwootchatwoot) Configure(ctx context.Context, req resource.Configure
 
 // chatwootchatwoot is the resource implementation.
 type chatwootchatwoot struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type chatwootchatwootModel struct {
@@ -52,12 +59,16 @@ type chatwootchatwootModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Databaseurl         types.String       `tfsdk:"database_url"`
 	Redisurl         types.String       `tfsdk:"redis_url"`
 	Secretkeybase         types.String       `tfsdk:"secret_key_base"`
 	Smtpaddress         types.String       `tfsdk:"smtp_address"`
-	Smtpport         types.String       `tfsdk:"smtp_port"`
+	Smtpport         types.Int64       `tfsdk:"smtp_port"`
 	Smtpusername         types.String       `tfsdk:"smtp_username"`
 	Smtppassword         types.String       `tfsdk:"smtp_password"`
 	Mailersenderemail         types.String       `tfsdk:"mailer_sender_email"`
@@ -68,9 +79,11 @@ func (r *chatwootchatwoot) Metadata(_ context.Context, req resource.MetadataRequ
 }
 
 // Schema defines the schema for the resource.
-func (r *chatwootchatwoot) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *chatwootchatwoot) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Transform your customer service with Chatwoot, the open-source platform that centralizes conversations across channels. Empower your team with AI-driven support, omnichannel integration, and insightful analytics.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Transform your customer service with Chatwoot, the open-source platform that centralizes conversations across channels. Empower your team with AI-driven support, omnichannel integration, and insightful analytics.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -88,43 +101,116 @@ func (r *chatwootchatwoot) Schema(_ context.Context, _ resource.SchemaRequest, r
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of chatwoot",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"database_url": schema.StringAttribute{
 				Required: true,
 				Description: "Database connection URL for PostgreSQL database that stores all Chatwoot data including conversations, contacts, agents, and configuration",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_url": schema.StringAttribute{
 				Required: true,
 				Description: "Redis connection URL used for caching, session storage, background job processing, and real-time features like live chat",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"secret_key_base": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Rails application secret key used for encrypting sessions, cookies, and other sensitive data within the application",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_address": schema.StringAttribute{
 				Optional: true,
 				Description: "SMTP server hostname or IP address for sending outbound emails including notifications, password resets, and conversation replies",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"smtp_port": schema.StringAttribute{
+			"smtp_port": schema.Int64Attribute{
 				Optional: true,
 				Description: "SMTP server port number for email delivery, typically 587 for TLS or 465 for SSL connections",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"smtp_username": schema.StringAttribute{
 				Optional: true,
 				Description: "Username for authenticating with the SMTP server when sending emails from Chatwoot",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_password": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Password or app-specific password for SMTP server authentication when sending emails",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"mailer_sender_email": schema.StringAttribute{
 				Optional: true,
 				Description: "Email address that appears as the sender for all outbound emails from Chatwoot including notifications and system messages",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -137,31 +223,59 @@ func (r *chatwootchatwoot) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("chatwoot-chatwoot")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "chatwoot-chatwoot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "chatwoot-chatwoot", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "chatwoot-chatwoot", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"DatabaseUrl": plan.Databaseurl.ValueString(),
-		"RedisUrl": plan.Redisurl.ValueString(),
-		"SecretKeyBase": plan.Secretkeybase.ValueString(),
-		"SmtpAddress": plan.Smtpaddress.ValueString(),
-		"SmtpPort": plan.Smtpport.ValueString(),
-		"SmtpUsername": plan.Smtpusername.ValueString(),
-		"SmtpPassword": plan.Smtppassword.ValueString(),
-		"MailerSenderEmail": plan.Mailersenderemail.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"DatabaseUrl": plan.Databaseurl,
+		"RedisUrl": plan.Redisurl,
+		"SecretKeyBase": plan.Secretkeybase,
+		"SmtpAddress": plan.Smtpaddress,
+		"SmtpPort": plan.Smtpport,
+		"SmtpUsername": plan.Smtpusername,
+		"SmtpPassword": plan.Smtppassword,
+		"MailerSenderEmail": plan.Mailersenderemail,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "chatwoot-chatwoot", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "chatwoot-chatwoot", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "chatwoot-chatwoot", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "chatwoot-chatwoot", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "chatwoot-chatwoot", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -176,10 +290,14 @@ func (r *chatwootchatwoot) Create(ctx context.Context, req resource.CreateReques
 
 	// Update the state with the actual data returned from the API
 	state := chatwootchatwootModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("chatwoot-chatwoot"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Databaseurl: plan.Databaseurl,
 		Redisurl: plan.Redisurl,
@@ -197,14 +315,174 @@ func (r *chatwootchatwoot) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "chatwoot-chatwoot", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "chatwoot-chatwoot", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *chatwootchatwoot) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state chatwootchatwootModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "chatwoot-chatwoot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "chatwoot-chatwoot", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "chatwoot-chatwoot", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["DatabaseUrl"].(string); ok && value != "" && state.Databaseurl.IsNull() {
+			state.Databaseurl = types.StringValue(value)
+		}
+		if value, ok := instance["RedisUrl"].(string); ok && value != "" && state.Redisurl.IsNull() {
+			state.Redisurl = types.StringValue(value)
+		}
+		if value, ok := instance["SmtpAddress"].(string); ok && value != "" && state.Smtpaddress.IsNull() {
+			state.Smtpaddress = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["SmtpPort"]); ok && state.Smtpport.IsNull() {
+			state.Smtpport = types.Int64Value(value)
+		}
+		if value, ok := instance["SmtpUsername"].(string); ok && value != "" && state.Smtpusername.IsNull() {
+			state.Smtpusername = types.StringValue(value)
+		}
+		if value, ok := instance["MailerSenderEmail"].(string); ok && value != "" && state.Mailersenderemail.IsNull() {
+			state.Mailersenderemail = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"database_url": state.Databaseurl,
+			"redis_url": state.Redisurl,
+			"secret_key_base": state.Secretkeybase,
+			"smtp_address": state.Smtpaddress,
+			"smtp_port": state.Smtpport,
+			"smtp_username": state.Smtpusername,
+			"smtp_password": state.Smtppassword,
+			"mailer_sender_email": state.Mailersenderemail,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("chatwoot-chatwoot")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *chatwootchatwoot) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *chatwootchatwoot) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("chatwoot-chatwoot", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *chatwootchatwoot) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state chatwootchatwootModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"database_url",
+		"redis_url",
+		"secret_key_base",
+		"smtp_address",
+		"smtp_port",
+		"smtp_username",
+		"smtp_password",
+		"mailer_sender_email",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -216,15 +494,30 @@ func (r *chatwootchatwoot) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("chatwoot-chatwoot")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "chatwoot-chatwoot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "chatwoot-chatwoot", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "chatwoot-chatwoot", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "chatwoot-chatwoot", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "chatwoot-chatwoot", state.Name.ValueString()))
 		return
 	}
 }
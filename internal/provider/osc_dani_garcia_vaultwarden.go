@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &danigarciavaultwarden{}
 	_ resource.ResourceWithConfigure = &danigarciavaultwarden{}
+	_ resource.ResourceWithImportState = &danigarciavaultwarden{}
+	_ resource.ResourceWithUpgradeState = &danigarciavaultwarden{}
 )
 
 func Newdanigarciavaultwarden() resource.Resource {
@@ -28,7 +35,7 @@ func (r *danigarciavaultwarden) Configure(ctx context.Context, req resource.Conf
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *danigarciavaultwarden) Configure(ctx context.Context, req resource.Conf
 
 // danigarciavaultwarden is the resource implementation.
 type danigarciavaultwarden struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type danigarciavaultwardenModel struct {
@@ -52,17 +59,21 @@ type danigarciavaultwardenModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Admintoken         types.String       `tfsdk:"admin_token"`
-	Webvaultenabled         bool       `tfsdk:"web_vault_enabled"`
+	Webvaultenabled         types.Bool       `tfsdk:"web_vault_enabled"`
 	Smtphost         types.String       `tfsdk:"smtp_host"`
-	Smtpport         types.String       `tfsdk:"smtp_port"`
+	Smtpport         types.Int64       `tfsdk:"smtp_port"`
 	Smtpfrom         types.String       `tfsdk:"smtp_from"`
 	Smtpusername         types.String       `tfsdk:"smtp_username"`
 	Smtppassword         types.String       `tfsdk:"smtp_password"`
-	Signupsallowed         bool       `tfsdk:"signups_allowed"`
-	Invitationsallowed         bool       `tfsdk:"invitations_allowed"`
-	Showpasswordhint         bool       `tfsdk:"show_password_hint"`
+	Signupsallowed         types.Bool       `tfsdk:"signups_allowed"`
+	Invitationsallowed         types.Bool       `tfsdk:"invitations_allowed"`
+	Showpasswordhint         types.Bool       `tfsdk:"show_password_hint"`
 	Databaseurl         types.String       `tfsdk:"database_url"`
 }
 
@@ -71,9 +82,11 @@ func (r *danigarciavaultwarden) Metadata(_ context.Context, req resource.Metadat
 }
 
 // Schema defines the schema for the resource.
-func (r *danigarciavaultwarden) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *danigarciavaultwarden) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Experience seamless, lightweight password management with Vaultwarden! Our Rust-based server implementation is fully compatible with Bitwarden clients, offering top-notch security for self-hosted setups without resource-heavy overhead.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Experience seamless, lightweight password management with Vaultwarden! Our Rust-based server implementation is fully compatible with Bitwarden clients, offering top-notch security for self-hosted setups without resource-heavy overhead.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -91,55 +104,137 @@ func (r *danigarciavaultwarden) Schema(_ context.Context, _ resource.SchemaReque
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of vaultwarden",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"admin_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Authentication token for accessing the Vaultwarden admin backend interface",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"web_vault_enabled": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether the web vault interface is enabled and accessible",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"smtp_host": schema.StringAttribute{
 				Optional: true,
 				Description: "SMTP server hostname or IP address for sending emails",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"smtp_port": schema.StringAttribute{
+			"smtp_port": schema.Int64Attribute{
 				Optional: true,
 				Description: "Port number for the SMTP server connection",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"smtp_from": schema.StringAttribute{
 				Optional: true,
 				Description: "Email address that appears as the sender for all outgoing emails from Vaultwarden",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_username": schema.StringAttribute{
 				Optional: true,
 				Description: "Username for authenticating with the SMTP server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_password": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Password for authenticating with the SMTP server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"signups_allowed": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether new users can create accounts directly on the Vaultwarden instance",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"invitations_allowed": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether existing users can invite new users to join the Vaultwarden instance",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"show_password_hint": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether password hints are displayed to users who request them",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"database_url": schema.StringAttribute{
 				Required: true,
 				Description: "Connection string for the database where Vaultwarden stores all data including user accounts, passwords, and organizational information",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -152,34 +247,62 @@ func (r *danigarciavaultwarden) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("dani-garcia-vaultwarden")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "dani-garcia-vaultwarden"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "dani-garcia-vaultwarden", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "dani-garcia-vaultwarden", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"adminToken": plan.Admintoken.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"adminToken": plan.Admintoken,
 		"webVaultEnabled": plan.Webvaultenabled,
-		"smtpHost": plan.Smtphost.ValueString(),
-		"smtpPort": plan.Smtpport.ValueString(),
-		"smtpFrom": plan.Smtpfrom.ValueString(),
-		"smtpUsername": plan.Smtpusername.ValueString(),
-		"smtpPassword": plan.Smtppassword.ValueString(),
+		"smtpHost": plan.Smtphost,
+		"smtpPort": plan.Smtpport,
+		"smtpFrom": plan.Smtpfrom,
+		"smtpUsername": plan.Smtpusername,
+		"smtpPassword": plan.Smtppassword,
 		"signupsAllowed": plan.Signupsallowed,
 		"invitationsAllowed": plan.Invitationsallowed,
 		"showPasswordHint": plan.Showpasswordhint,
-		"databaseUrl": plan.Databaseurl.ValueString(),
+		"databaseUrl": plan.Databaseurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "dani-garcia-vaultwarden", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "dani-garcia-vaultwarden", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "dani-garcia-vaultwarden", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "dani-garcia-vaultwarden", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "dani-garcia-vaultwarden", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -194,10 +317,14 @@ func (r *danigarciavaultwarden) Create(ctx context.Context, req resource.CreateR
 
 	// Update the state with the actual data returned from the API
 	state := danigarciavaultwardenModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("dani-garcia-vaultwarden"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Admintoken: plan.Admintoken,
 		Webvaultenabled: plan.Webvaultenabled,
@@ -218,14 +345,189 @@ func (r *danigarciavaultwarden) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "dani-garcia-vaultwarden", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "dani-garcia-vaultwarden", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *danigarciavaultwarden) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state danigarciavaultwardenModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "dani-garcia-vaultwarden"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "dani-garcia-vaultwarden", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "dani-garcia-vaultwarden", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["webVaultEnabled"].(bool); ok && state.Webvaultenabled.IsNull() {
+			state.Webvaultenabled = types.BoolValue(value)
+		}
+		if value, ok := instance["smtpHost"].(string); ok && value != "" && state.Smtphost.IsNull() {
+			state.Smtphost = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["smtpPort"]); ok && state.Smtpport.IsNull() {
+			state.Smtpport = types.Int64Value(value)
+		}
+		if value, ok := instance["smtpFrom"].(string); ok && value != "" && state.Smtpfrom.IsNull() {
+			state.Smtpfrom = types.StringValue(value)
+		}
+		if value, ok := instance["smtpUsername"].(string); ok && value != "" && state.Smtpusername.IsNull() {
+			state.Smtpusername = types.StringValue(value)
+		}
+		if value, ok := instance["signupsAllowed"].(bool); ok && state.Signupsallowed.IsNull() {
+			state.Signupsallowed = types.BoolValue(value)
+		}
+		if value, ok := instance["invitationsAllowed"].(bool); ok && state.Invitationsallowed.IsNull() {
+			state.Invitationsallowed = types.BoolValue(value)
+		}
+		if value, ok := instance["showPasswordHint"].(bool); ok && state.Showpasswordhint.IsNull() {
+			state.Showpasswordhint = types.BoolValue(value)
+		}
+		if value, ok := instance["databaseUrl"].(string); ok && value != "" && state.Databaseurl.IsNull() {
+			state.Databaseurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"admin_token": state.Admintoken,
+			"web_vault_enabled": state.Webvaultenabled,
+			"smtp_host": state.Smtphost,
+			"smtp_port": state.Smtpport,
+			"smtp_from": state.Smtpfrom,
+			"smtp_username": state.Smtpusername,
+			"smtp_password": state.Smtppassword,
+			"signups_allowed": state.Signupsallowed,
+			"invitations_allowed": state.Invitationsallowed,
+			"show_password_hint": state.Showpasswordhint,
+			"database_url": state.Databaseurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("dani-garcia-vaultwarden")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *danigarciavaultwarden) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *danigarciavaultwarden) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("dani-garcia-vaultwarden", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *danigarciavaultwarden) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state danigarciavaultwardenModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"admin_token",
+		"web_vault_enabled",
+		"smtp_host",
+		"smtp_port",
+		"smtp_from",
+		"smtp_username",
+		"smtp_password",
+		"signups_allowed",
+		"invitations_allowed",
+		"show_password_hint",
+		"database_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -237,15 +539,30 @@ func (r *danigarciavaultwarden) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("dani-garcia-vaultwarden")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "dani-garcia-vaultwarden"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "dani-garcia-vaultwarden", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "dani-garcia-vaultwarden", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "dani-garcia-vaultwarden", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "dani-garcia-vaultwarden", state.Name.ValueString()))
 		return
 	}
 }
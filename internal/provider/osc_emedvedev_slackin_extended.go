@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &emedvedevslackinextended{}
 	_ resource.ResourceWithConfigure = &emedvedevslackinextended{}
+	_ resource.ResourceWithImportState = &emedvedevslackinextended{}
+	_ resource.ResourceWithUpgradeState = &emedvedevslackinextended{}
 )
 
 func Newemedvedevslackinextended() resource.Resource {
@@ -28,7 +33,7 @@ func (r *emedvedevslackinextended) Configure(ctx context.Context, req resource.C
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *emedvedevslackinextended) Configure(ctx context.Context, req resource.C
 
 // emedvedevslackinextended is the resource implementation.
 type emedvedevslackinextended struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type emedvedevslackinextendedModel struct {
@@ -52,6 +57,10 @@ type emedvedevslackinextendedModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Slackworkspaceid         types.String       `tfsdk:"slack_workspace_id"`
 	Slackapitoken         types.String       `tfsdk:"slack_api_token"`
@@ -67,9 +76,11 @@ func (r *emedvedevslackinextended) Metadata(_ context.Context, req resource.Meta
 }
 
 // Schema defines the schema for the resource.
-func (r *emedvedevslackinextended) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *emedvedevslackinextended) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Boost your Slack community engagement with Slackin-Extended! Our customizable platform offers real-time user tracking, effortless invites, and abuse prevention. Enhance user experience with personalized themes and simple integration options. Perfect for building and maintaining a vibrant online community!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Boost your Slack community engagement with Slackin-Extended! Our customizable platform offers real-time user tracking, effortless invites, and abuse prevention. Enhance user experience with personalized themes and simple integration options. Perfect for building and maintaining a vibrant online community!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -87,39 +98,106 @@ func (r *emedvedevslackinextended) Schema(_ context.Context, _ resource.SchemaRe
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of slackin-extended",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_workspace_id": schema.StringAttribute{
 				Required: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_api_token": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_invite_url": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"recaptcha_secret": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"recaptcha_sitekey": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"theme": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"co_c_url": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -132,30 +210,58 @@ func (r *emedvedevslackinextended) Create(ctx context.Context, req resource.Crea
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("emedvedev-slackin-extended")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "emedvedev-slackin-extended"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "emedvedev-slackin-extended", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "emedvedev-slackin-extended", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"SlackWorkspaceId": plan.Slackworkspaceid.ValueString(),
-		"SlackApiToken": plan.Slackapitoken.ValueString(),
-		"SlackInviteUrl": plan.Slackinviteurl.ValueString(),
-		"RecaptchaSecret": plan.Recaptchasecret.ValueString(),
-		"RecaptchaSitekey": plan.Recaptchasitekey.ValueString(),
-		"Theme": plan.Theme.ValueString(),
-		"CoCUrl": plan.Cocurl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"SlackWorkspaceId": plan.Slackworkspaceid,
+		"SlackApiToken": plan.Slackapitoken,
+		"SlackInviteUrl": plan.Slackinviteurl,
+		"RecaptchaSecret": plan.Recaptchasecret,
+		"RecaptchaSitekey": plan.Recaptchasitekey,
+		"Theme": plan.Theme,
+		"CoCUrl": plan.Cocurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "emedvedev-slackin-extended", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "emedvedev-slackin-extended", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "emedvedev-slackin-extended", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "emedvedev-slackin-extended", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "emedvedev-slackin-extended", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -170,10 +276,14 @@ func (r *emedvedevslackinextended) Create(ctx context.Context, req resource.Crea
 
 	// Update the state with the actual data returned from the API
 	state := emedvedevslackinextendedModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("emedvedev-slackin-extended"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Slackworkspaceid: plan.Slackworkspaceid,
 		Slackapitoken: plan.Slackapitoken,
@@ -190,14 +300,169 @@ func (r *emedvedevslackinextended) Create(ctx context.Context, req resource.Crea
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "emedvedev-slackin-extended", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "emedvedev-slackin-extended", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *emedvedevslackinextended) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state emedvedevslackinextendedModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "emedvedev-slackin-extended"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "emedvedev-slackin-extended", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "emedvedev-slackin-extended", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["SlackWorkspaceId"].(string); ok && value != "" && state.Slackworkspaceid.IsNull() {
+			state.Slackworkspaceid = types.StringValue(value)
+		}
+		if value, ok := instance["SlackInviteUrl"].(string); ok && value != "" && state.Slackinviteurl.IsNull() {
+			state.Slackinviteurl = types.StringValue(value)
+		}
+		if value, ok := instance["RecaptchaSitekey"].(string); ok && value != "" && state.Recaptchasitekey.IsNull() {
+			state.Recaptchasitekey = types.StringValue(value)
+		}
+		if value, ok := instance["Theme"].(string); ok && value != "" && state.Theme.IsNull() {
+			state.Theme = types.StringValue(value)
+		}
+		if value, ok := instance["CoCUrl"].(string); ok && value != "" && state.Cocurl.IsNull() {
+			state.Cocurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"slack_workspace_id": state.Slackworkspaceid,
+			"slack_api_token": state.Slackapitoken,
+			"slack_invite_url": state.Slackinviteurl,
+			"recaptcha_secret": state.Recaptchasecret,
+			"recaptcha_sitekey": state.Recaptchasitekey,
+			"theme": state.Theme,
+			"co_c_url": state.Cocurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("emedvedev-slackin-extended")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *emedvedevslackinextended) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *emedvedevslackinextended) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("emedvedev-slackin-extended", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *emedvedevslackinextended) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state emedvedevslackinextendedModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"slack_workspace_id",
+		"slack_api_token",
+		"slack_invite_url",
+		"recaptcha_secret",
+		"recaptcha_sitekey",
+		"theme",
+		"co_c_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -209,15 +474,30 @@ func (r *emedvedevslackinextended) Delete(ctx context.Context, req resource.Dele
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("emedvedev-slackin-extended")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "emedvedev-slackin-extended"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "emedvedev-slackin-extended", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "emedvedev-slackin-extended", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "emedvedev-slackin-extended", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "emedvedev-slackin-extended", state.Name.ValueString()))
 		return
 	}
 }
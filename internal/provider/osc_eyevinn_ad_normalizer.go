@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &eyevinnadnormalizer{}
 	_ resource.ResourceWithConfigure = &eyevinnadnormalizer{}
+	_ resource.ResourceWithImportState = &eyevinnadnormalizer{}
+	_ resource.ResourceWithUpgradeState = &eyevinnadnormalizer{}
 )
 
 func Neweyevinnadnormalizer() resource.Resource {
@@ -28,7 +33,7 @@ func (r *eyevinnadnormalizer) Configure(ctx context.Context, req resource.Config
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *eyevinnadnormalizer) Configure(ctx context.Context, req resource.Config
 
 // eyevinnadnormalizer is the resource implementation.
 type eyevinnadnormalizer struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinnadnormalizerModel struct {
@@ -52,6 +57,10 @@ type eyevinnadnormalizerModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Encoreurl         types.String       `tfsdk:"encore_url"`
 	Redisurl         types.String       `tfsdk:"redis_url"`
@@ -61,7 +70,7 @@ type eyevinnadnormalizerModel struct {
 	Keyfield         types.String       `tfsdk:"key_field"`
 	Encoreprofile         types.String       `tfsdk:"encore_profile"`
 	Assetserverurl         types.String       `tfsdk:"asset_server_url"`
-	Jitpackaging         bool       `tfsdk:"jit_packaging"`
+	Jitpackaging         types.Bool       `tfsdk:"jit_packaging"`
 	Packagingqueuename         types.String       `tfsdk:"packaging_queue_name"`
 	Oscaccesstoken         types.String       `tfsdk:"osc_access_token"`
 }
@@ -71,9 +80,11 @@ func (r *eyevinnadnormalizer) Metadata(_ context.Context, req resource.MetadataR
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinnadnormalizer) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinnadnormalizer) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Optimize your ad delivery with Ad Normalizer! Seamlessly transcode and package VAST creatives for your ad server using a Redis-backed workflow. Ensure efficient media processing and reliable ad streaming.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Optimize your ad delivery with Ad Normalizer! Seamlessly transcode and package VAST creatives for your ad server using a Redis-backed workflow. Ensure efficient media processing and reliable ad streaming.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -91,55 +102,133 @@ func (r *eyevinnadnormalizer) Schema(_ context.Context, _ resource.SchemaRequest
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of ad-normalizer",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"encore_url": schema.StringAttribute{
 				Required: true,
 				Description: "URL of the related encore instance",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_url": schema.StringAttribute{
 				Optional: true,
 				Description: "The url to the redis/valkey instance used. Should use the redis protocol and ideally include port",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"ad_server_url": schema.StringAttribute{
 				Required: true,
 				Description: "The url to the ad server endpoint. For the test ad server the path should be /api/v1/ads",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"output_bucket_url": schema.StringAttribute{
 				Required: true,
 				Description: "The url to the output folder for the packaged assets",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"key_regex": schema.StringAttribute{
 				Optional: true,
 				Description: "Defaults to [^a-zA-Z0-9] if not set",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"key_field": schema.StringAttribute{
 				Optional: true,
 				Description: "Which field that the normalizer should use as key in valkey/redis. Optional, defaults to universalAdId if not set",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"encore_profile": schema.StringAttribute{
 				Optional: true,
-				Description: "Optional, defaults to &#34;program&#34; if not set",
+				Description: "Optional, defaults to \"program\" if not set",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"asset_server_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Optional, http version of OUTPUT_BUCKET_URL is used if not set",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"jit_packaging": schema.BoolAttribute{
 				Optional: true,
 				Description: "Signals wether packaging of ads is done JIT or if completed jobs should be put on the packaging queue. optional, defaults to false if not provided",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"packaging_queue_name": schema.StringAttribute{
 				Optional: true,
-				Description: "Name of the redis queue used for packaging jobs. Optional, defaults to &#34;package&#34; if not provided",
+				Description: "Name of the redis queue used for packaging jobs. Optional, defaults to \"package\" if not provided",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"osc_access_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Access token for Eyevinn Open Source Cloud (OSC) when running Encore in that environment",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -152,34 +241,62 @@ func (r *eyevinnadnormalizer) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-ad-normalizer")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-ad-normalizer"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-ad-normalizer", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-ad-normalizer", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"EncoreUrl": plan.Encoreurl.ValueString(),
-		"RedisUrl": plan.Redisurl.ValueString(),
-		"AdServerUrl": plan.Adserverurl.ValueString(),
-		"OutputBucketUrl": plan.Outputbucketurl.ValueString(),
-		"KeyRegex": plan.Keyregex.ValueString(),
-		"KeyField": plan.Keyfield.ValueString(),
-		"EncoreProfile": plan.Encoreprofile.ValueString(),
-		"AssetServerUrl": plan.Assetserverurl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"EncoreUrl": plan.Encoreurl,
+		"RedisUrl": plan.Redisurl,
+		"AdServerUrl": plan.Adserverurl,
+		"OutputBucketUrl": plan.Outputbucketurl,
+		"KeyRegex": plan.Keyregex,
+		"KeyField": plan.Keyfield,
+		"EncoreProfile": plan.Encoreprofile,
+		"AssetServerUrl": plan.Assetserverurl,
 		"JitPackaging": plan.Jitpackaging,
-		"PackagingQueueName": plan.Packagingqueuename.ValueString(),
-		"OscAccessToken": plan.Oscaccesstoken.ValueString(),
+		"PackagingQueueName": plan.Packagingqueuename,
+		"OscAccessToken": plan.Oscaccesstoken,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-ad-normalizer", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-ad-normalizer", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-ad-normalizer", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-ad-normalizer", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-ad-normalizer", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -194,10 +311,14 @@ func (r *eyevinnadnormalizer) Create(ctx context.Context, req resource.CreateReq
 
 	// Update the state with the actual data returned from the API
 	state := eyevinnadnormalizerModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-ad-normalizer"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Encoreurl: plan.Encoreurl,
 		Redisurl: plan.Redisurl,
@@ -218,14 +339,192 @@ func (r *eyevinnadnormalizer) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-ad-normalizer", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-ad-normalizer", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinnadnormalizer) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinnadnormalizerModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-ad-normalizer"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-ad-normalizer", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-ad-normalizer", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["EncoreUrl"].(string); ok && value != "" && state.Encoreurl.IsNull() {
+			state.Encoreurl = types.StringValue(value)
+		}
+		if value, ok := instance["RedisUrl"].(string); ok && value != "" && state.Redisurl.IsNull() {
+			state.Redisurl = types.StringValue(value)
+		}
+		if value, ok := instance["AdServerUrl"].(string); ok && value != "" && state.Adserverurl.IsNull() {
+			state.Adserverurl = types.StringValue(value)
+		}
+		if value, ok := instance["OutputBucketUrl"].(string); ok && value != "" && state.Outputbucketurl.IsNull() {
+			state.Outputbucketurl = types.StringValue(value)
+		}
+		if value, ok := instance["KeyRegex"].(string); ok && value != "" && state.Keyregex.IsNull() {
+			state.Keyregex = types.StringValue(value)
+		}
+		if value, ok := instance["KeyField"].(string); ok && value != "" && state.Keyfield.IsNull() {
+			state.Keyfield = types.StringValue(value)
+		}
+		if value, ok := instance["EncoreProfile"].(string); ok && value != "" && state.Encoreprofile.IsNull() {
+			state.Encoreprofile = types.StringValue(value)
+		}
+		if value, ok := instance["AssetServerUrl"].(string); ok && value != "" && state.Assetserverurl.IsNull() {
+			state.Assetserverurl = types.StringValue(value)
+		}
+		if value, ok := instance["JitPackaging"].(bool); ok && state.Jitpackaging.IsNull() {
+			state.Jitpackaging = types.BoolValue(value)
+		}
+		if value, ok := instance["PackagingQueueName"].(string); ok && value != "" && state.Packagingqueuename.IsNull() {
+			state.Packagingqueuename = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"encore_url": state.Encoreurl,
+			"redis_url": state.Redisurl,
+			"ad_server_url": state.Adserverurl,
+			"output_bucket_url": state.Outputbucketurl,
+			"key_regex": state.Keyregex,
+			"key_field": state.Keyfield,
+			"encore_profile": state.Encoreprofile,
+			"asset_server_url": state.Assetserverurl,
+			"jit_packaging": state.Jitpackaging,
+			"packaging_queue_name": state.Packagingqueuename,
+			"osc_access_token": state.Oscaccesstoken,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-ad-normalizer")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinnadnormalizer) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinnadnormalizer) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-ad-normalizer", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinnadnormalizer) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinnadnormalizerModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"encore_url",
+		"redis_url",
+		"ad_server_url",
+		"output_bucket_url",
+		"key_regex",
+		"key_field",
+		"encore_profile",
+		"asset_server_url",
+		"jit_packaging",
+		"packaging_queue_name",
+		"osc_access_token",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -237,15 +536,30 @@ func (r *eyevinnadnormalizer) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-ad-normalizer")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-ad-normalizer"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-ad-normalizer", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-ad-normalizer", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-ad-normalizer", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-ad-normalizer", state.Name.ValueString()))
 		return
 	}
 }
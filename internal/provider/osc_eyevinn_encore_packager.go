@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &eyevinnencorepackager{}
 	_ resource.ResourceWithConfigure = &eyevinnencorepackager{}
+	_ resource.ResourceWithImportState = &eyevinnencorepackager{}
+	_ resource.ResourceWithUpgradeState = &eyevinnencorepackager{}
 )
 
 func Neweyevinnencorepackager() resource.Resource {
@@ -28,7 +35,7 @@ func (r *eyevinnencorepackager) Configure(ctx context.Context, req resource.Conf
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *eyevinnencorepackager) Configure(ctx context.Context, req resource.Conf
 
 // eyevinnencorepackager is the resource implementation.
 type eyevinnencorepackager struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinnencorepackagerModel struct {
@@ -52,11 +59,15 @@ type eyevinnencorepackagerModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Redisurl         types.String       `tfsdk:"redis_url"`
 	Redisqueue         types.String       `tfsdk:"redis_queue"`
 	Outputfolder         types.String       `tfsdk:"output_folder"`
-	Concurrency         types.String       `tfsdk:"concurrency"`
+	Concurrency         types.Int64       `tfsdk:"concurrency"`
 	Personalaccesstoken         types.String       `tfsdk:"personal_access_token"`
 	Awsaccesskeyid         types.String       `tfsdk:"aws_access_key_id"`
 	Awssecretaccesskey         types.String       `tfsdk:"aws_secret_access_key"`
@@ -64,7 +75,7 @@ type eyevinnencorepackagerModel struct {
 	Awssessiontoken         types.String       `tfsdk:"aws_session_token"`
 	S3endpointurl         types.String       `tfsdk:"s3_endpoint_url"`
 	Outputsubfoldertemplate         types.String       `tfsdk:"output_subfolder_template"`
-	Skippackaging         bool       `tfsdk:"skip_packaging"`
+	Skippackaging         types.Bool       `tfsdk:"skip_packaging"`
 	Callbackurl         types.String       `tfsdk:"callback_url"`
 }
 
@@ -73,9 +84,11 @@ func (r *eyevinnencorepackager) Metadata(_ context.Context, req resource.Metadat
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinnencorepackager) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinnencorepackager) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Enhance your transcoding workflow with Encore packager! Run as a service, listen for messages on redis queue, and customize packaging events. Boost productivity with this versatile tool.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Enhance your transcoding workflow with Encore packager! Run as a service, listen for messages on redis queue, and customize packaging events. Boost productivity with this versatile tool.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -93,63 +106,153 @@ func (r *eyevinnencorepackager) Schema(_ context.Context, _ resource.SchemaReque
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of encore-packager",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_url": schema.StringAttribute{
 				Required: true,
 				Description: "URL to the Redis server used for message queuing when running as a service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_queue": schema.StringAttribute{
 				Optional: true,
 				Description: "Name of the Redis queue to listen to for packaging job messages",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"output_folder": schema.StringAttribute{
 				Required: true,
 				Description: "Base folder for packaging output, with actual output stored in subfolders according to OUTPUT_SUBFOLDER_TEMPLATE",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"concurrency": schema.StringAttribute{
+			"concurrency": schema.Int64Attribute{
 				Optional: true,
 				Description: "Number of concurrent packaging jobs that can be processed simultaneously",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"personal_access_token": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "OSC (Open Source Cloud) access token for accessing Encore instances hosted in OSC",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_access_key_id": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "AWS access key ID for authentication when PACKAGE_OUTPUT_FOLDER is an AWS S3 bucket",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_secret_access_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "AWS secret access key for authentication when PACKAGE_OUTPUT_FOLDER is an AWS S3 bucket",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_region": schema.StringAttribute{
 				Optional: true,
 				Description: "AWS region specification for S3 bucket operations",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_session_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "AWS session token for temporary credential authentication with S3",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_endpoint_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Custom S3 endpoint URL when PACKAGE_OUTPUT_FOLDER is an S3 bucket not hosted on AWS",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"output_subfolder_template": schema.StringAttribute{
 				Optional: true,
 				Description: "Template for subfolder structure relative to PACKAGE_OUTPUT_FOLDER where output will be stored",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"skip_packaging": schema.BoolAttribute{
 				Optional: true,
 				Description: "When enable the output files are copied and a SMIL file is created",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"callback_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Optional callback service URL for receiving packaging success or failure notifications",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -162,36 +265,64 @@ func (r *eyevinnencorepackager) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-encore-packager")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-encore-packager"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-encore-packager", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-encore-packager", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"RedisUrl": plan.Redisurl.ValueString(),
-		"RedisQueue": plan.Redisqueue.ValueString(),
-		"OutputFolder": plan.Outputfolder.ValueString(),
-		"Concurrency": plan.Concurrency.ValueString(),
-		"PersonalAccessToken": plan.Personalaccesstoken.ValueString(),
-		"AwsAccessKeyId": plan.Awsaccesskeyid.ValueString(),
-		"AwsSecretAccessKey": plan.Awssecretaccesskey.ValueString(),
-		"AwsRegion": plan.Awsregion.ValueString(),
-		"AwsSessionToken": plan.Awssessiontoken.ValueString(),
-		"S3EndpointUrl": plan.S3endpointurl.ValueString(),
-		"OutputSubfolderTemplate": plan.Outputsubfoldertemplate.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"RedisUrl": plan.Redisurl,
+		"RedisQueue": plan.Redisqueue,
+		"OutputFolder": plan.Outputfolder,
+		"Concurrency": plan.Concurrency,
+		"PersonalAccessToken": plan.Personalaccesstoken,
+		"AwsAccessKeyId": plan.Awsaccesskeyid,
+		"AwsSecretAccessKey": plan.Awssecretaccesskey,
+		"AwsRegion": plan.Awsregion,
+		"AwsSessionToken": plan.Awssessiontoken,
+		"S3EndpointUrl": plan.S3endpointurl,
+		"OutputSubfolderTemplate": plan.Outputsubfoldertemplate,
 		"SkipPackaging": plan.Skippackaging,
-		"CallbackUrl": plan.Callbackurl.ValueString(),
+		"CallbackUrl": plan.Callbackurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-encore-packager", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-encore-packager", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-encore-packager", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-encore-packager", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-encore-packager", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -206,10 +337,14 @@ func (r *eyevinnencorepackager) Create(ctx context.Context, req resource.CreateR
 
 	// Update the state with the actual data returned from the API
 	state := eyevinnencorepackagerModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-encore-packager"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Redisurl: plan.Redisurl,
 		Redisqueue: plan.Redisqueue,
@@ -232,14 +367,193 @@ func (r *eyevinnencorepackager) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-encore-packager", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-encore-packager", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinnencorepackager) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinnencorepackagerModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-encore-packager"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-encore-packager", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-encore-packager", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["RedisUrl"].(string); ok && value != "" && state.Redisurl.IsNull() {
+			state.Redisurl = types.StringValue(value)
+		}
+		if value, ok := instance["RedisQueue"].(string); ok && value != "" && state.Redisqueue.IsNull() {
+			state.Redisqueue = types.StringValue(value)
+		}
+		if value, ok := instance["OutputFolder"].(string); ok && value != "" && state.Outputfolder.IsNull() {
+			state.Outputfolder = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["Concurrency"]); ok && state.Concurrency.IsNull() {
+			state.Concurrency = types.Int64Value(value)
+		}
+		if value, ok := instance["AwsRegion"].(string); ok && value != "" && state.Awsregion.IsNull() {
+			state.Awsregion = types.StringValue(value)
+		}
+		if value, ok := instance["S3EndpointUrl"].(string); ok && value != "" && state.S3endpointurl.IsNull() {
+			state.S3endpointurl = types.StringValue(value)
+		}
+		if value, ok := instance["OutputSubfolderTemplate"].(string); ok && value != "" && state.Outputsubfoldertemplate.IsNull() {
+			state.Outputsubfoldertemplate = types.StringValue(value)
+		}
+		if value, ok := instance["SkipPackaging"].(bool); ok && state.Skippackaging.IsNull() {
+			state.Skippackaging = types.BoolValue(value)
+		}
+		if value, ok := instance["CallbackUrl"].(string); ok && value != "" && state.Callbackurl.IsNull() {
+			state.Callbackurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"redis_url": state.Redisurl,
+			"redis_queue": state.Redisqueue,
+			"output_folder": state.Outputfolder,
+			"concurrency": state.Concurrency,
+			"personal_access_token": state.Personalaccesstoken,
+			"aws_access_key_id": state.Awsaccesskeyid,
+			"aws_secret_access_key": state.Awssecretaccesskey,
+			"aws_region": state.Awsregion,
+			"aws_session_token": state.Awssessiontoken,
+			"s3_endpoint_url": state.S3endpointurl,
+			"output_subfolder_template": state.Outputsubfoldertemplate,
+			"skip_packaging": state.Skippackaging,
+			"callback_url": state.Callbackurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-encore-packager")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinnencorepackager) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinnencorepackager) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-encore-packager", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinnencorepackager) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinnencorepackagerModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"redis_url",
+		"redis_queue",
+		"output_folder",
+		"concurrency",
+		"personal_access_token",
+		"aws_access_key_id",
+		"aws_secret_access_key",
+		"aws_region",
+		"aws_session_token",
+		"s3_endpoint_url",
+		"output_subfolder_template",
+		"skip_packaging",
+		"callback_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -251,15 +565,30 @@ func (r *eyevinnencorepackager) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-encore-packager")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-encore-packager"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-encore-packager", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-encore-packager", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-encore-packager", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-encore-packager", state.Name.ValueString()))
 		return
 	}
 }
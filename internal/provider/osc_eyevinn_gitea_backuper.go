@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &eyevinngiteabackuper{}
 	_ resource.ResourceWithConfigure = &eyevinngiteabackuper{}
+	_ resource.ResourceWithImportState = &eyevinngiteabackuper{}
+	_ resource.ResourceWithUpgradeState = &eyevinngiteabackuper{}
 )
 
 func Neweyevinngiteabackuper() resource.Resource {
@@ -28,7 +33,7 @@ func (r *eyevinngiteabackuper) Configure(ctx context.Context, req resource.Confi
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *eyevinngiteabackuper) Configure(ctx context.Context, req resource.Confi
 
 // eyevinngiteabackuper is the resource implementation.
 type eyevinngiteabackuper struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinngiteabackuperModel struct {
@@ -52,6 +57,10 @@ type eyevinngiteabackuperModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Operation         types.String       `tfsdk:"operation"`
 	Giteaurl         types.String       `tfsdk:"gitea_url"`
@@ -70,9 +79,11 @@ func (r *eyevinngiteabackuper) Metadata(_ context.Context, req resource.Metadata
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinngiteabackuper) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinngiteabackuper) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Secure your Gitea instances effortlessly with gitea-backuper! Perform full Git mirror backups and restorations with encryption support on MinIO/S3-compatible storage. Efficient, reliable, and simple backup management!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Secure your Gitea instances effortlessly with gitea-backuper! Perform full Git mirror backups and restorations with encryption support on MinIO/S3-compatible storage. Efficient, reliable, and simple backup management!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -90,51 +101,129 @@ func (r *eyevinngiteabackuper) Schema(_ context.Context, _ resource.SchemaReques
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of gitea-backuper",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"operation": schema.StringAttribute{
 				Required: true,
 				Description: "Specifies the operation to perform on the Gitea instance",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"gitea_url": schema.StringAttribute{
 				Required: true,
 				Description: "The base URL of the Gitea instance to backup or restore",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"gitea_token": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Admin API token for authenticating with the Gitea instance",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_endpoint": schema.StringAttribute{
 				Optional: true,
 				Description: "The endpoint URL for the MinIO or S3-compatible storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_bucket": schema.StringAttribute{
 				Optional: true,
 				Description: "The name of the S3/MinIO bucket where backups will be stored or retrieved from",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_object_key": schema.StringAttribute{
 				Optional: true,
 				Description: "The specific object key (file path) within the S3 bucket for the backup archive",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_access_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "The access key for authenticating with the S3/MinIO storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_secret_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "The secret key for authenticating with the S3/MinIO storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_region": schema.StringAttribute{
 				Optional: true,
 				Description: "The AWS region for the S3 service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"encryption_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "AES-256-CBC passphrase for encrypting or decrypting the backup archive",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -147,33 +236,61 @@ func (r *eyevinngiteabackuper) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-gitea-backuper")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
-		return
-	}
-
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-gitea-backuper", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"Operation": plan.Operation.ValueString(),
-		"GiteaUrl": plan.Giteaurl.ValueString(),
-		"GiteaToken": plan.Giteatoken.ValueString(),
-		"S3Endpoint": plan.S3endpoint.ValueString(),
-		"S3Bucket": plan.S3bucket.ValueString(),
-		"S3ObjectKey": plan.S3objectkey.ValueString(),
-		"S3AccessKey": plan.S3accesskey.ValueString(),
-		"S3SecretKey": plan.S3secretkey.ValueString(),
-		"S3Region": plan.S3region.ValueString(),
-		"EncryptionKey": plan.Encryptionkey.ValueString(),
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-gitea-backuper"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-gitea-backuper", plan.Name.ValueString()))
+		return
+	}
+
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"Operation": plan.Operation,
+		"GiteaUrl": plan.Giteaurl,
+		"GiteaToken": plan.Giteatoken,
+		"S3Endpoint": plan.S3endpoint,
+		"S3Bucket": plan.S3bucket,
+		"S3ObjectKey": plan.S3objectkey,
+		"S3AccessKey": plan.S3accesskey,
+		"S3SecretKey": plan.S3secretkey,
+		"S3Region": plan.S3region,
+		"EncryptionKey": plan.Encryptionkey,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-gitea-backuper", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-gitea-backuper", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-gitea-backuper", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-gitea-backuper", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-gitea-backuper", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -188,10 +305,14 @@ func (r *eyevinngiteabackuper) Create(ctx context.Context, req resource.CreateRe
 
 	// Update the state with the actual data returned from the API
 	state := eyevinngiteabackuperModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-gitea-backuper"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Operation: plan.Operation,
 		Giteaurl: plan.Giteaurl,
@@ -211,14 +332,178 @@ func (r *eyevinngiteabackuper) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-gitea-backuper", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-gitea-backuper", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinngiteabackuper) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinngiteabackuperModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-gitea-backuper"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-gitea-backuper", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-gitea-backuper", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["Operation"].(string); ok && value != "" && state.Operation.IsNull() {
+			state.Operation = types.StringValue(value)
+		}
+		if value, ok := instance["GiteaUrl"].(string); ok && value != "" && state.Giteaurl.IsNull() {
+			state.Giteaurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3Endpoint"].(string); ok && value != "" && state.S3endpoint.IsNull() {
+			state.S3endpoint = types.StringValue(value)
+		}
+		if value, ok := instance["S3Bucket"].(string); ok && value != "" && state.S3bucket.IsNull() {
+			state.S3bucket = types.StringValue(value)
+		}
+		if value, ok := instance["S3ObjectKey"].(string); ok && value != "" && state.S3objectkey.IsNull() {
+			state.S3objectkey = types.StringValue(value)
+		}
+		if value, ok := instance["S3Region"].(string); ok && value != "" && state.S3region.IsNull() {
+			state.S3region = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"operation": state.Operation,
+			"gitea_url": state.Giteaurl,
+			"gitea_token": state.Giteatoken,
+			"s3_endpoint": state.S3endpoint,
+			"s3_bucket": state.S3bucket,
+			"s3_object_key": state.S3objectkey,
+			"s3_access_key": state.S3accesskey,
+			"s3_secret_key": state.S3secretkey,
+			"s3_region": state.S3region,
+			"encryption_key": state.Encryptionkey,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-gitea-backuper")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinngiteabackuper) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinngiteabackuper) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-gitea-backuper", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinngiteabackuper) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinngiteabackuperModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"operation",
+		"gitea_url",
+		"gitea_token",
+		"s3_endpoint",
+		"s3_bucket",
+		"s3_object_key",
+		"s3_access_key",
+		"s3_secret_key",
+		"s3_region",
+		"encryption_key",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -230,15 +515,30 @@ func (r *eyevinngiteabackuper) Delete(ctx context.Context, req resource.DeleteRe
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-gitea-backuper")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-gitea-backuper"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-gitea-backuper", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-gitea-backuper", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-gitea-backuper", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-gitea-backuper", state.Name.ValueString()))
 		return
 	}
 }
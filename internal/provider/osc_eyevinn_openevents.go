@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &eyevinnopenevents{}
 	_ resource.ResourceWithConfigure = &eyevinnopenevents{}
+	_ resource.ResourceWithImportState = &eyevinnopenevents{}
+	_ resource.ResourceWithUpgradeState = &eyevinnopenevents{}
 )
 
 func Neweyevinnopenevents() resource.Resource {
@@ -28,7 +35,7 @@ func (r *eyevinnopenevents) Configure(ctx context.Context, req resource.Configur
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *eyevinnopenevents) Configure(ctx context.Context, req resource.Configur
 
 // eyevinnopenevents is the resource implementation.
 type eyevinnopenevents struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinnopeneventsModel struct {
@@ -52,6 +59,10 @@ type eyevinnopeneventsModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Nextauthsecret         types.String       `tfsdk:"nextauth_secret"`
 	Stripesecretkey         types.String       `tfsdk:"stripe_secret_key"`
@@ -63,7 +74,7 @@ type eyevinnopeneventsModel struct {
 	S3accesskeyid         types.String       `tfsdk:"s3_access_key_id"`
 	S3secretaccesskey         types.String       `tfsdk:"s3_secret_access_key"`
 	Smtphost         types.String       `tfsdk:"smtp_host"`
-	Smtpport         types.String       `tfsdk:"smtp_port"`
+	Smtpport         types.Int64       `tfsdk:"smtp_port"`
 	Smtpuser         types.String       `tfsdk:"smtp_user"`
 	Smtppassword         types.String       `tfsdk:"smtp_password"`
 	Fromemail         types.String       `tfsdk:"from_email"`
@@ -77,9 +88,11 @@ func (r *eyevinnopenevents) Metadata(_ context.Context, req resource.MetadataReq
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinnopenevents) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinnopenevents) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Streamline your event management with OpenEvents, the comprehensive platform for dynamic event planning and seamless ticketing. Enhance attendee experience with real-time tracking, multiple ticketing options, and secure payment integration, all effortlessly managed through an intuitive dashboard.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Streamline your event management with OpenEvents, the comprehensive platform for dynamic event planning and seamless ticketing. Enhance attendee experience with real-time tracking, multiple ticketing options, and secure payment integration, all effortlessly managed through an intuitive dashboard.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -97,79 +110,183 @@ func (r *eyevinnopenevents) Schema(_ context.Context, _ resource.SchemaRequest,
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of openevents",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"nextauth_secret": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Secret key used by NextAuth.js for encrypting JWT tokens and session data",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"stripe_secret_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Stripe secret API key for processing online payments",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"stripe_publishable_key": schema.StringAttribute{
 				Required: true,
 				Description: "Stripe publishable API key for client-side payment form integration",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"stripe_webhook_secret": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Stripe webhook endpoint secret for verifying payment event notifications",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_endpoint": schema.StringAttribute{
 				Required: true,
 				Description: "S3-compatible storage endpoint URL for file uploads",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_region": schema.StringAttribute{
 				Required: true,
 				Description: "AWS region or S3-compatible storage region setting",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_bucket_name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of the S3 bucket for storing uploaded files",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_access_key_id": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Access key ID for S3-compatible storage authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_secret_access_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Secret access key for S3-compatible storage authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_host": schema.StringAttribute{
 				Optional: true,
 				Description: "SMTP server hostname for sending emails",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"smtp_port": schema.StringAttribute{
+			"smtp_port": schema.Int64Attribute{
 				Optional: true,
 				Description: "SMTP server port number for email delivery",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"smtp_user": schema.StringAttribute{
 				Optional: true,
 				Description: "Username for SMTP server authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"smtp_password": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Password for SMTP server authentication",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"from_email": schema.StringAttribute{
 				Optional: true,
 				Description: "Email address used as the sender for outgoing emails",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"site_name": schema.StringAttribute{
 				Optional: true,
 				Description: "Name of the event platform displayed in the application",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"site_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Base URL of the deployed application",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"database_url": schema.StringAttribute{
 				Required: true,
 				Description: "PostgreSQL database connection string",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -182,40 +299,68 @@ func (r *eyevinnopenevents) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-openevents")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-openevents"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-openevents", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-openevents", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"nextauthSecret": plan.Nextauthsecret.ValueString(),
-		"stripeSecretKey": plan.Stripesecretkey.ValueString(),
-		"stripePublishableKey": plan.Stripepublishablekey.ValueString(),
-		"stripeWebhookSecret": plan.Stripewebhooksecret.ValueString(),
-		"s3Endpoint": plan.S3endpoint.ValueString(),
-		"s3Region": plan.S3region.ValueString(),
-		"s3BucketName": plan.S3bucketname.ValueString(),
-		"s3AccessKeyId": plan.S3accesskeyid.ValueString(),
-		"s3SecretAccessKey": plan.S3secretaccesskey.ValueString(),
-		"smtpHost": plan.Smtphost.ValueString(),
-		"smtpPort": plan.Smtpport.ValueString(),
-		"smtpUser": plan.Smtpuser.ValueString(),
-		"smtpPassword": plan.Smtppassword.ValueString(),
-		"fromEmail": plan.Fromemail.ValueString(),
-		"siteName": plan.Sitename.ValueString(),
-		"siteUrl": plan.Siteurl.ValueString(),
-		"databaseUrl": plan.Databaseurl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"nextauthSecret": plan.Nextauthsecret,
+		"stripeSecretKey": plan.Stripesecretkey,
+		"stripePublishableKey": plan.Stripepublishablekey,
+		"stripeWebhookSecret": plan.Stripewebhooksecret,
+		"s3Endpoint": plan.S3endpoint,
+		"s3Region": plan.S3region,
+		"s3BucketName": plan.S3bucketname,
+		"s3AccessKeyId": plan.S3accesskeyid,
+		"s3SecretAccessKey": plan.S3secretaccesskey,
+		"smtpHost": plan.Smtphost,
+		"smtpPort": plan.Smtpport,
+		"smtpUser": plan.Smtpuser,
+		"smtpPassword": plan.Smtppassword,
+		"fromEmail": plan.Fromemail,
+		"siteName": plan.Sitename,
+		"siteUrl": plan.Siteurl,
+		"databaseUrl": plan.Databaseurl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-openevents", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-openevents", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-openevents", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-openevents", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-openevents", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -230,10 +375,14 @@ func (r *eyevinnopenevents) Create(ctx context.Context, req resource.CreateReque
 
 	// Update the state with the actual data returned from the API
 	state := eyevinnopeneventsModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-openevents"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Nextauthsecret: plan.Nextauthsecret,
 		Stripesecretkey: plan.Stripesecretkey,
@@ -260,14 +409,207 @@ func (r *eyevinnopenevents) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-openevents", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-openevents", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinnopenevents) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinnopeneventsModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-openevents"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-openevents", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-openevents", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["stripePublishableKey"].(string); ok && value != "" && state.Stripepublishablekey.IsNull() {
+			state.Stripepublishablekey = types.StringValue(value)
+		}
+		if value, ok := instance["s3Endpoint"].(string); ok && value != "" && state.S3endpoint.IsNull() {
+			state.S3endpoint = types.StringValue(value)
+		}
+		if value, ok := instance["s3Region"].(string); ok && value != "" && state.S3region.IsNull() {
+			state.S3region = types.StringValue(value)
+		}
+		if value, ok := instance["s3BucketName"].(string); ok && value != "" && state.S3bucketname.IsNull() {
+			state.S3bucketname = types.StringValue(value)
+		}
+		if value, ok := instance["smtpHost"].(string); ok && value != "" && state.Smtphost.IsNull() {
+			state.Smtphost = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["smtpPort"]); ok && state.Smtpport.IsNull() {
+			state.Smtpport = types.Int64Value(value)
+		}
+		if value, ok := instance["smtpUser"].(string); ok && value != "" && state.Smtpuser.IsNull() {
+			state.Smtpuser = types.StringValue(value)
+		}
+		if value, ok := instance["fromEmail"].(string); ok && value != "" && state.Fromemail.IsNull() {
+			state.Fromemail = types.StringValue(value)
+		}
+		if value, ok := instance["siteName"].(string); ok && value != "" && state.Sitename.IsNull() {
+			state.Sitename = types.StringValue(value)
+		}
+		if value, ok := instance["siteUrl"].(string); ok && value != "" && state.Siteurl.IsNull() {
+			state.Siteurl = types.StringValue(value)
+		}
+		if value, ok := instance["databaseUrl"].(string); ok && value != "" && state.Databaseurl.IsNull() {
+			state.Databaseurl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"nextauth_secret": state.Nextauthsecret,
+			"stripe_secret_key": state.Stripesecretkey,
+			"stripe_publishable_key": state.Stripepublishablekey,
+			"stripe_webhook_secret": state.Stripewebhooksecret,
+			"s3_endpoint": state.S3endpoint,
+			"s3_region": state.S3region,
+			"s3_bucket_name": state.S3bucketname,
+			"s3_access_key_id": state.S3accesskeyid,
+			"s3_secret_access_key": state.S3secretaccesskey,
+			"smtp_host": state.Smtphost,
+			"smtp_port": state.Smtpport,
+			"smtp_user": state.Smtpuser,
+			"smtp_password": state.Smtppassword,
+			"from_email": state.Fromemail,
+			"site_name": state.Sitename,
+			"site_url": state.Siteurl,
+			"database_url": state.Databaseurl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-openevents")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinnopenevents) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinnopenevents) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-openevents", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinnopenevents) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinnopeneventsModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"nextauth_secret",
+		"stripe_secret_key",
+		"stripe_publishable_key",
+		"stripe_webhook_secret",
+		"s3_endpoint",
+		"s3_region",
+		"s3_bucket_name",
+		"s3_access_key_id",
+		"s3_secret_access_key",
+		"smtp_host",
+		"smtp_port",
+		"smtp_user",
+		"smtp_password",
+		"from_email",
+		"site_name",
+		"site_url",
+		"database_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -279,15 +621,30 @@ func (r *eyevinnopenevents) Delete(ctx context.Context, req resource.DeleteReque
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-openevents")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-openevents"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-openevents", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-openevents", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-openevents", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-openevents", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &eyevinnplayeranalyticsworker{}
 	_ resource.ResourceWithConfigure = &eyevinnplayeranalyticsworker{}
+	_ resource.ResourceWithImportState = &eyevinnplayeranalyticsworker{}
+	_ resource.ResourceWithUpgradeState = &eyevinnplayeranalyticsworker{}
 )
 
 func Neweyevinnplayeranalyticsworker() resource.Resource {
@@ -28,7 +35,7 @@ func (r *eyevinnplayeranalyticsworker) Configure(ctx context.Context, req resour
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *eyevinnplayeranalyticsworker) Configure(ctx context.Context, req resour
 
 // eyevinnplayeranalyticsworker is the resource implementation.
 type eyevinnplayeranalyticsworker struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinnplayeranalyticsworkerModel struct {
@@ -52,14 +59,18 @@ type eyevinnplayeranalyticsworkerModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Clickhouseurl         types.String       `tfsdk:"click_house_url"`
 	Sqsqueueurl         types.String       `tfsdk:"sqs_queue_url"`
 	Awsaccesskeyid         types.String       `tfsdk:"aws_access_key_id"`
 	Awssecretaccesskey         types.String       `tfsdk:"aws_secret_access_key"`
 	Sqsendpoint         types.String       `tfsdk:"sqs_endpoint"`
-	Numworkers         types.String       `tfsdk:"num_workers"`
-	Batchsize         types.String       `tfsdk:"batch_size"`
+	Numworkers         types.Int64       `tfsdk:"num_workers"`
+	Batchsize         types.Int64       `tfsdk:"batch_size"`
 }
 
 func (r *eyevinnplayeranalyticsworker) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -67,9 +78,11 @@ func (r *eyevinnplayeranalyticsworker) Metadata(_ context.Context, req resource.
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinnplayeranalyticsworker) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinnplayeranalyticsworker) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Unlock powerful insights with Eyevinn Player Analytics Worker – the modular framework designed to streamline video player event tracking. Effortlessly process and store event data, boosting your analytics game!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Unlock powerful insights with Eyevinn Player Analytics Worker – the modular framework designed to streamline video player event tracking. Effortlessly process and store event data, boosting your analytics game!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -87,39 +100,112 @@ func (r *eyevinnplayeranalyticsworker) Schema(_ context.Context, _ resource.Sche
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of player-analytics-worker",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"click_house_url": schema.StringAttribute{
 				Required: true,
 				Description: "The connection URL for the ClickHouse database where processed analytics events will be stored",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"sqs_queue_url": schema.StringAttribute{
 				Required: true,
 				Description: "The AWS SQS queue URL from which the worker will poll for analytics events to process",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_access_key_id": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "AWS access key ID for authenticating with SQS services to read analytics events from the queue",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"aws_secret_access_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "AWS secret access key for authenticating with SQS services to read analytics events from the queue",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"sqs_endpoint": schema.StringAttribute{
 				Optional: true,
 				Description: "Custom SQS endpoint URL for connecting to SQS services hosted outside of standard AWS regions",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"num_workers": schema.StringAttribute{
+			"num_workers": schema.Int64Attribute{
 				Optional: true,
 				Description: "The number of worker processes to spawn for processing analytics events from the SQS queue",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
-			"batch_size": schema.StringAttribute{
+			"batch_size": schema.Int64Attribute{
 				Optional: true,
 				Description: "The maximum number of messages to retrieve from the SQS queue in a single batch operation",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -132,30 +218,58 @@ func (r *eyevinnplayeranalyticsworker) Create(ctx context.Context, req resource.
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-player-analytics-worker")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-player-analytics-worker"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-player-analytics-worker", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-player-analytics-worker", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"ClickHouseUrl": plan.Clickhouseurl.ValueString(),
-		"SqsQueueUrl": plan.Sqsqueueurl.ValueString(),
-		"AwsAccessKeyId": plan.Awsaccesskeyid.ValueString(),
-		"AwsSecretAccessKey": plan.Awssecretaccesskey.ValueString(),
-		"SqsEndpoint": plan.Sqsendpoint.ValueString(),
-		"NumWorkers": plan.Numworkers.ValueString(),
-		"BatchSize": plan.Batchsize.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"ClickHouseUrl": plan.Clickhouseurl,
+		"SqsQueueUrl": plan.Sqsqueueurl,
+		"AwsAccessKeyId": plan.Awsaccesskeyid,
+		"AwsSecretAccessKey": plan.Awssecretaccesskey,
+		"SqsEndpoint": plan.Sqsendpoint,
+		"NumWorkers": plan.Numworkers,
+		"BatchSize": plan.Batchsize,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-player-analytics-worker", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-player-analytics-worker", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-player-analytics-worker", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-player-analytics-worker", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-player-analytics-worker", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -170,10 +284,14 @@ func (r *eyevinnplayeranalyticsworker) Create(ctx context.Context, req resource.
 
 	// Update the state with the actual data returned from the API
 	state := eyevinnplayeranalyticsworkerModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-player-analytics-worker"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Clickhouseurl: plan.Clickhouseurl,
 		Sqsqueueurl: plan.Sqsqueueurl,
@@ -190,14 +308,169 @@ func (r *eyevinnplayeranalyticsworker) Create(ctx context.Context, req resource.
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-player-analytics-worker", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-player-analytics-worker", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinnplayeranalyticsworker) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinnplayeranalyticsworkerModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-player-analytics-worker"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-player-analytics-worker", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-player-analytics-worker", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["ClickHouseUrl"].(string); ok && value != "" && state.Clickhouseurl.IsNull() {
+			state.Clickhouseurl = types.StringValue(value)
+		}
+		if value, ok := instance["SqsQueueUrl"].(string); ok && value != "" && state.Sqsqueueurl.IsNull() {
+			state.Sqsqueueurl = types.StringValue(value)
+		}
+		if value, ok := instance["SqsEndpoint"].(string); ok && value != "" && state.Sqsendpoint.IsNull() {
+			state.Sqsendpoint = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["NumWorkers"]); ok && state.Numworkers.IsNull() {
+			state.Numworkers = types.Int64Value(value)
+		}
+		if value, ok := int64FromParameter(instance["BatchSize"]); ok && state.Batchsize.IsNull() {
+			state.Batchsize = types.Int64Value(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"click_house_url": state.Clickhouseurl,
+			"sqs_queue_url": state.Sqsqueueurl,
+			"aws_access_key_id": state.Awsaccesskeyid,
+			"aws_secret_access_key": state.Awssecretaccesskey,
+			"sqs_endpoint": state.Sqsendpoint,
+			"num_workers": state.Numworkers,
+			"batch_size": state.Batchsize,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-player-analytics-worker")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinnplayeranalyticsworker) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinnplayeranalyticsworker) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-player-analytics-worker", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinnplayeranalyticsworker) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinnplayeranalyticsworkerModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"click_house_url",
+		"sqs_queue_url",
+		"aws_access_key_id",
+		"aws_secret_access_key",
+		"sqs_endpoint",
+		"num_workers",
+		"batch_size",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -209,15 +482,30 @@ func (r *eyevinnplayeranalyticsworker) Delete(ctx context.Context, req resource.
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-player-analytics-worker")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-player-analytics-worker"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-player-analytics-worker", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-player-analytics-worker", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-player-analytics-worker", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-player-analytics-worker", state.Name.ValueString()))
 		return
 	}
 }
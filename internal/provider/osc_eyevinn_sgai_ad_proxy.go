@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &eyevinnsgaiadproxy{}
 	_ resource.ResourceWithConfigure = &eyevinnsgaiadproxy{}
+	_ resource.ResourceWithImportState = &eyevinnsgaiadproxy{}
+	_ resource.ResourceWithUpgradeState = &eyevinnsgaiadproxy{}
 )
 
 func Neweyevinnsgaiadproxy() resource.Resource {
@@ -28,7 +35,7 @@ func (r *eyevinnsgaiadproxy) Configure(ctx context.Context, req resource.Configu
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *eyevinnsgaiadproxy) Configure(ctx context.Context, req resource.Configu
 
 // eyevinnsgaiadproxy is the resource implementation.
 type eyevinnsgaiadproxy struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type eyevinnsgaiadproxyModel struct {
@@ -52,14 +59,18 @@ type eyevinnsgaiadproxyModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Vastendpoint         types.String       `tfsdk:"vast_endpoint"`
 	Originhost         types.String       `tfsdk:"origin_host"`
 	Originurl         types.String       `tfsdk:"origin_url"`
 	Insertionmode         types.String       `tfsdk:"insertion_mode"`
-	Defaultadduration         types.String       `tfsdk:"default_ad_duration"`
+	Defaultadduration         types.Int64       `tfsdk:"default_ad_duration"`
 	Defaultrepeatingcycle         types.String       `tfsdk:"default_repeating_cycle"`
-	Defaultadnumber         types.String       `tfsdk:"default_ad_number"`
+	Defaultadnumber         types.Int64       `tfsdk:"default_ad_number"`
 	Testasseturl         types.String       `tfsdk:"test_asset_url"`
 }
 
@@ -68,9 +79,11 @@ func (r *eyevinnsgaiadproxy) Metadata(_ context.Context, req resource.MetadataRe
 }
 
 // Schema defines the schema for the resource.
-func (r *eyevinnsgaiadproxy) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *eyevinnsgaiadproxy) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Boost viewer engagement with our Server-Guided Ad Insertion Proxy! Automatically embed ads into video streams with precision timing. Enhance monetization effortlessly while maintaining a seamless user experience.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Boost viewer engagement with our Server-Guided Ad Insertion Proxy! Automatically embed ads into video streams with precision timing. Enhance monetization effortlessly while maintaining a seamless user experience.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -88,43 +101,117 @@ func (r *eyevinnsgaiadproxy) Schema(_ context.Context, _ resource.SchemaRequest,
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of sgai-ad-proxy",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"vast_endpoint": schema.StringAttribute{
 				Required: true,
 				Description: "The VAST 4.0/4.1 XML compatible ad server endpoint URL that provides advertisement content",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"origin_host": schema.StringAttribute{
 				Required: true,
 				Description: "The hostname or IP address of the origin HLS streaming server that provides the source video content",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"origin_url": schema.StringAttribute{
 				Optional: true,
 				Description: "The complete URL to the master playlist of the origin HLS stream",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"insertion_mode": schema.StringAttribute{
 				Required: true,
 				Description: "The ad insertion mode to use for placing advertisements in the stream",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"default_ad_duration": schema.StringAttribute{
+			"default_ad_duration": schema.Int64Attribute{
 				Optional: true,
 				Description: "The default duration in seconds for ad breaks when not specified",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"default_repeating_cycle": schema.StringAttribute{
 				Optional: true,
 				Description: "The interval in seconds at which ad breaks repeat in static insertion mode",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"default_ad_number": schema.StringAttribute{
+			"default_ad_number": schema.Int64Attribute{
 				Optional: true,
 				Description: "The default number of ad slots to generate in static insertion mode",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"test_asset_url": schema.StringAttribute{
 				Optional: true,
 				Description: "A test asset URL to replace raw MP4 assets with a fragmented MP4 VoD media playlist for better compatibility",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -137,31 +224,59 @@ func (r *eyevinnsgaiadproxy) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-sgai-ad-proxy")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-sgai-ad-proxy"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-sgai-ad-proxy", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "eyevinn-sgai-ad-proxy", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"VastEndpoint": plan.Vastendpoint.ValueString(),
-		"OriginHost": plan.Originhost.ValueString(),
-		"OriginUrl": plan.Originurl.ValueString(),
-		"InsertionMode": plan.Insertionmode.ValueString(),
-		"DefaultAdDuration": plan.Defaultadduration.ValueString(),
-		"DefaultRepeatingCycle": plan.Defaultrepeatingcycle.ValueString(),
-		"DefaultAdNumber": plan.Defaultadnumber.ValueString(),
-		"TestAssetUrl": plan.Testasseturl.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"VastEndpoint": plan.Vastendpoint,
+		"OriginHost": plan.Originhost,
+		"OriginUrl": plan.Originurl,
+		"InsertionMode": plan.Insertionmode,
+		"DefaultAdDuration": plan.Defaultadduration,
+		"DefaultRepeatingCycle": plan.Defaultrepeatingcycle,
+		"DefaultAdNumber": plan.Defaultadnumber,
+		"TestAssetUrl": plan.Testasseturl,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "eyevinn-sgai-ad-proxy", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "eyevinn-sgai-ad-proxy", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-sgai-ad-proxy", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -176,10 +291,14 @@ func (r *eyevinnsgaiadproxy) Create(ctx context.Context, req resource.CreateRequ
 
 	// Update the state with the actual data returned from the API
 	state := eyevinnsgaiadproxyModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("eyevinn-sgai-ad-proxy"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Vastendpoint: plan.Vastendpoint,
 		Originhost: plan.Originhost,
@@ -197,14 +316,180 @@ func (r *eyevinnsgaiadproxy) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "eyevinn-sgai-ad-proxy", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *eyevinnsgaiadproxy) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state eyevinnsgaiadproxyModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-sgai-ad-proxy"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["VastEndpoint"].(string); ok && value != "" && state.Vastendpoint.IsNull() {
+			state.Vastendpoint = types.StringValue(value)
+		}
+		if value, ok := instance["OriginHost"].(string); ok && value != "" && state.Originhost.IsNull() {
+			state.Originhost = types.StringValue(value)
+		}
+		if value, ok := instance["OriginUrl"].(string); ok && value != "" && state.Originurl.IsNull() {
+			state.Originurl = types.StringValue(value)
+		}
+		if value, ok := instance["InsertionMode"].(string); ok && value != "" && state.Insertionmode.IsNull() {
+			state.Insertionmode = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["DefaultAdDuration"]); ok && state.Defaultadduration.IsNull() {
+			state.Defaultadduration = types.Int64Value(value)
+		}
+		if value, ok := instance["DefaultRepeatingCycle"].(string); ok && value != "" && state.Defaultrepeatingcycle.IsNull() {
+			state.Defaultrepeatingcycle = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["DefaultAdNumber"]); ok && state.Defaultadnumber.IsNull() {
+			state.Defaultadnumber = types.Int64Value(value)
+		}
+		if value, ok := instance["TestAssetUrl"].(string); ok && value != "" && state.Testasseturl.IsNull() {
+			state.Testasseturl = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"vast_endpoint": state.Vastendpoint,
+			"origin_host": state.Originhost,
+			"origin_url": state.Originurl,
+			"insertion_mode": state.Insertionmode,
+			"default_ad_duration": state.Defaultadduration,
+			"default_repeating_cycle": state.Defaultrepeatingcycle,
+			"default_ad_number": state.Defaultadnumber,
+			"test_asset_url": state.Testasseturl,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("eyevinn-sgai-ad-proxy")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *eyevinnsgaiadproxy) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *eyevinnsgaiadproxy) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("eyevinn-sgai-ad-proxy", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *eyevinnsgaiadproxy) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state eyevinnsgaiadproxyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"vast_endpoint",
+		"origin_host",
+		"origin_url",
+		"insertion_mode",
+		"default_ad_duration",
+		"default_repeating_cycle",
+		"default_ad_number",
+		"test_asset_url",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -216,15 +501,30 @@ func (r *eyevinnsgaiadproxy) Delete(ctx context.Context, req resource.DeleteRequ
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("eyevinn-sgai-ad-proxy")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "eyevinn-sgai-ad-proxy"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "eyevinn-sgai-ad-proxy", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "eyevinn-sgai-ad-proxy", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "eyevinn-sgai-ad-proxy", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &grusellencoreprofileserver{}
 	_ resource.ResourceWithConfigure = &grusellencoreprofileserver{}
+	_ resource.ResourceWithImportState = &grusellencoreprofileserver{}
+	_ resource.ResourceWithUpgradeState = &grusellencoreprofileserver{}
 )
 
 func Newgrusellencoreprofileserver() resource.Resource {
@@ -28,7 +33,7 @@ func (r *grusellencoreprofileserver) Configure(ctx context.Context, req resource
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *grusellencoreprofileserver) Configure(ctx context.Context, req resource
 
 // grusellencoreprofileserver is the resource implementation.
 type grusellencoreprofileserver struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type grusellencoreprofileserverModel struct {
@@ -52,6 +57,10 @@ type grusellencoreprofileserverModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	S3endpoint         types.String       `tfsdk:"s3_endpoint"`
 	S3region         types.String       `tfsdk:"s3_region"`
@@ -68,9 +77,11 @@ func (r *grusellencoreprofileserver) Metadata(_ context.Context, req resource.Me
 }
 
 // Schema defines the schema for the resource.
-func (r *grusellencoreprofileserver) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *grusellencoreprofileserver) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Transform your video processing with the Encore Profile Server. Serve dynamic transcoding profiles directly from S3-compatible storage, seamlessly integrating AI capabilities for on-demand profile creation.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Transform your video processing with the Encore Profile Server. Serve dynamic transcoding profiles directly from S3-compatible storage, seamlessly integrating AI capabilities for on-demand profile creation.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -88,43 +99,114 @@ func (r *grusellencoreprofileserver) Schema(_ context.Context, _ resource.Schema
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of encore-profile-server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_endpoint": schema.StringAttribute{
 				Optional: true,
 				Description: "The endpoint URL for the S3-compatible storage service where Encore transcoding profiles are stored",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_region": schema.StringAttribute{
 				Optional: true,
 				Description: "The AWS region or region identifier for the S3-compatible storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_access_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "The access key ID for authenticating with the S3-compatible storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_secret_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "The secret access key for authenticating with the S3-compatible storage service",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_bucket": schema.StringAttribute{
 				Optional: true,
 				Description: "The name of the S3 bucket containing the Encore transcoding profile files (YAML/JSON)",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_prefix": schema.StringAttribute{
 				Optional: true,
 				Description: "Optional prefix path within the S3 bucket to limit profile file discovery to a specific directory/folder",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"anthropic_api_key": schema.StringAttribute{
 				Optional: true,
-				Description: "API key for accessing Anthropic&#39;s Claude AI service to enable AI-powered profile generation via the /feelinglucky endpoint",
+				Sensitive: true,
+				Description: "API key for accessing Anthropic's Claude AI service to enable AI-powered profile generation via the /feelinglucky endpoint",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"anthropic_model": schema.StringAttribute{
 				Optional: true,
 				Description: "Specifies which Claude AI model to use for generating Encore transcoding profiles",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -137,31 +219,59 @@ func (r *grusellencoreprofileserver) Create(ctx context.Context, req resource.Cr
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("grusell-encore-profile-server")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "grusell-encore-profile-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "grusell-encore-profile-server", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "grusell-encore-profile-server", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"s3Endpoint": plan.S3endpoint.ValueString(),
-		"s3Region": plan.S3region.ValueString(),
-		"s3AccessKey": plan.S3accesskey.ValueString(),
-		"s3SecretKey": plan.S3secretkey.ValueString(),
-		"s3Bucket": plan.S3bucket.ValueString(),
-		"s3Prefix": plan.S3prefix.ValueString(),
-		"anthropicApiKey": plan.Anthropicapikey.ValueString(),
-		"anthropicModel": plan.Anthropicmodel.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"s3Endpoint": plan.S3endpoint,
+		"s3Region": plan.S3region,
+		"s3AccessKey": plan.S3accesskey,
+		"s3SecretKey": plan.S3secretkey,
+		"s3Bucket": plan.S3bucket,
+		"s3Prefix": plan.S3prefix,
+		"anthropicApiKey": plan.Anthropicapikey,
+		"anthropicModel": plan.Anthropicmodel,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "grusell-encore-profile-server", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "grusell-encore-profile-server", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "grusell-encore-profile-server", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "grusell-encore-profile-server", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "grusell-encore-profile-server", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -176,10 +286,14 @@ func (r *grusellencoreprofileserver) Create(ctx context.Context, req resource.Cr
 
 	// Update the state with the actual data returned from the API
 	state := grusellencoreprofileserverModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("grusell-encore-profile-server"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		S3endpoint: plan.S3endpoint,
 		S3region: plan.S3region,
@@ -197,14 +311,171 @@ func (r *grusellencoreprofileserver) Create(ctx context.Context, req resource.Cr
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "grusell-encore-profile-server", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "grusell-encore-profile-server", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *grusellencoreprofileserver) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state grusellencoreprofileserverModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "grusell-encore-profile-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "grusell-encore-profile-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "grusell-encore-profile-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["s3Endpoint"].(string); ok && value != "" && state.S3endpoint.IsNull() {
+			state.S3endpoint = types.StringValue(value)
+		}
+		if value, ok := instance["s3Region"].(string); ok && value != "" && state.S3region.IsNull() {
+			state.S3region = types.StringValue(value)
+		}
+		if value, ok := instance["s3Bucket"].(string); ok && value != "" && state.S3bucket.IsNull() {
+			state.S3bucket = types.StringValue(value)
+		}
+		if value, ok := instance["s3Prefix"].(string); ok && value != "" && state.S3prefix.IsNull() {
+			state.S3prefix = types.StringValue(value)
+		}
+		if value, ok := instance["anthropicModel"].(string); ok && value != "" && state.Anthropicmodel.IsNull() {
+			state.Anthropicmodel = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"s3_endpoint": state.S3endpoint,
+			"s3_region": state.S3region,
+			"s3_access_key": state.S3accesskey,
+			"s3_secret_key": state.S3secretkey,
+			"s3_bucket": state.S3bucket,
+			"s3_prefix": state.S3prefix,
+			"anthropic_api_key": state.Anthropicapikey,
+			"anthropic_model": state.Anthropicmodel,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("grusell-encore-profile-server")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *grusellencoreprofileserver) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *grusellencoreprofileserver) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("grusell-encore-profile-server", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *grusellencoreprofileserver) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state grusellencoreprofileserverModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"s3_endpoint",
+		"s3_region",
+		"s3_access_key",
+		"s3_secret_key",
+		"s3_bucket",
+		"s3_prefix",
+		"anthropic_api_key",
+		"anthropic_model",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -216,15 +487,30 @@ func (r *grusellencoreprofileserver) Delete(ctx context.Context, req resource.De
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("grusell-encore-profile-server")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "grusell-encore-profile-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "grusell-encore-profile-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "grusell-encore-profile-server", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "grusell-encore-profile-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "grusell-encore-profile-server", state.Name.ValueString()))
 		return
 	}
 }
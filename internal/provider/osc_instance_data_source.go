@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &InstanceDataSource{}
+	_ datasource.DataSourceWithConfigure = &InstanceDataSource{}
+)
+
+func init() {
+	RegisteredDataSources = append(RegisteredDataSources, NewInstanceDataSource)
+}
+
+// NewInstanceDataSource is a helper function to simplify the provider implementation.
+func NewInstanceDataSource() datasource.DataSource {
+	return &InstanceDataSource{}
+}
+
+func (d *InstanceDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	osaasContext, ok := req.ProviderData.(*oscClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *OscClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.osaasContext = osaasContext
+}
+
+// InstanceDataSource is the data source implementation.
+type InstanceDataSource struct {
+	osaasContext *oscClient
+}
+
+type InstanceDataSourceModel struct {
+	ServiceId           types.String            `tfsdk:"service_id"`
+	Name                types.String            `tfsdk:"name"`
+	InstanceUrl         types.String            `tfsdk:"instance_url"`
+	Ports               []InstancePortModel     `tfsdk:"ports"`
+	Parameters          map[string]types.String `tfsdk:"parameters"`
+	SensitiveParameters map[string]types.String `tfsdk:"sensitive_parameters"`
+}
+
+// Metadata returns the data source type name.
+func (d *InstanceDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance"
+}
+
+// Schema defines the schema for the data source.
+func (d *InstanceDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Look up an existing instance of an OSC service",
+		Attributes: map[string]schema.Attribute{
+			"service_id": schema.StringAttribute{
+				Required:    true,
+				Description: "The service id of the instance, e.g. 'valkey-io-valkey'",
+			},
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "The name of the instance",
+			},
+			"instance_url": schema.StringAttribute{
+				Computed:    true,
+				Description: "URL to the instance",
+			},
+			"ports": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The ports exposed by the instance",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed:    true,
+							Description: "The port inside the instance",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed:    true,
+							Description: "The externally reachable port",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed:    true,
+							Description: "The externally reachable IP",
+						},
+						"protocol": schema.StringAttribute{
+							Computed:    true,
+							Description: "The protocol of the port (if reported by the service)",
+						},
+					},
+				},
+			},
+			"parameters": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "The parameters the instance was created with, except those whose name suggests a credential",
+			},
+			"sensitive_parameters": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The parameters the instance was created with whose name suggests a credential, e.g. a password or token",
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *InstanceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config InstanceDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	serviceId := config.ServiceId.ValueString()
+	name := config.Name.ValueString()
+
+	// A service access token is only fetched for a subscribed service, as
+	// fetching one subscribes the tenant and a lookup must not do that
+	if _, err := getService(ctx, d.osaasContext, serviceId); err != nil {
+		if errors.Is(err, errServiceNotSubscribed) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("service_id"),
+				"Service not subscribed",
+				fmt.Sprintf("Not subscribed to service %q, so it has no instance named %q.", serviceId, name),
+			)
+			return
+		}
+		resp.Diagnostics.AddError("Failed to get service", err.Error())
+		return
+	}
+
+	instance, err := getInstance(ctx, d.osaasContext, serviceId, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", err.Error())
+		return
+	}
+	if instance == nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Instance not found",
+			fmt.Sprintf("Service %q has no instance named %q.", serviceId, name),
+		)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, d.osaasContext, serviceId, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		return
+	}
+
+	parameters, sensitiveParameters := instanceParameters(instance)
+	state := InstanceDataSourceModel{
+		ServiceId:           config.ServiceId,
+		Name:                config.Name,
+		InstanceUrl:         types.StringNull(),
+		Ports:               instancePortModels(ports),
+		Parameters:          parameters,
+		SensitiveParameters: sensitiveParameters,
+	}
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readInstanceDataSource reads the instance data source for the given
+// service and instance name against an OSC API served by handler.
+func readInstanceDataSource(t *testing.T, handler http.HandlerFunc, serviceId string, name string) (*datasource.ReadResponse, InstanceDataSourceModel) {
+	t.Helper()
+	ctx := context.Background()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	osaasContext, err := osaasclient.NewContext(&osaasclient.ContextConfig{PersonalAccessToken: "pat", Environment: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &InstanceDataSource{osaasContext: newOscClient(osaasContext, server.URL, server.URL, server.URL, noRetry)}
+
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for attribute, attributeType := range objectType.AttributeTypes {
+		values[attribute] = tftypes.NewValue(attributeType, nil)
+	}
+	values["service_id"] = tftypes.NewValue(tftypes.String, serviceId)
+	values["name"] = tftypes.NewValue(tftypes.String, name)
+
+	req := datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	d.Read(ctx, req, resp)
+
+	var state InstanceDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	}
+	return resp, state
+}
+
+// testOSC serves the subscriptions, service access tokens, instances and ports
+// of a tenant subscribed to svc, counting the subscriptions made.
+func testOSC(subscribes *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/mysubscriptions" && r.Method == http.MethodPost:
+			subscribes.Add(1)
+		case r.URL.Path == "/mysubscriptions":
+			_, _ = fmt.Fprintf(w, `[{"serviceId":"svc","apiUrl":"http://%s/svc"}]`, r.Host)
+		case r.URL.Path == "/servicetoken":
+			_, _ = w.Write([]byte(`{"serviceId":"svc","token":"sat"}`))
+		case r.URL.Path == "/svc/example":
+			_, _ = w.Write([]byte(`{"name":"example","url":"https://example.svc.osaas.io","Title":"Example","Workers":2,"AdminPassword":"secret"}`))
+		case r.URL.Path == "/ports/example":
+			_, _ = w.Write([]byte(`[{"externalIp":"10.0.0.1","externalPort":10001,"internalPort":8080}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestInstanceDataSourceRead(t *testing.T) {
+	var subscribes atomic.Int32
+	resp, state := readInstanceDataSource(t, testOSC(&subscribes), "svc", "example")
+
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if state.InstanceUrl.ValueString() != "https://example.svc.osaas.io" {
+		t.Errorf("unexpected instance url %s", state.InstanceUrl)
+	}
+	if len(state.Ports) != 1 || state.Ports[0].ExternalPort.ValueInt32() != 10001 {
+		t.Errorf("unexpected ports %v", state.Ports)
+	}
+	if state.Parameters["Title"].ValueString() != "Example" || state.Parameters["Workers"].ValueString() != "2" {
+		t.Errorf("unexpected parameters %v", state.Parameters)
+	}
+	if _, ok := state.Parameters["AdminPassword"]; ok {
+		t.Error("expected the password to be left out of the parameters")
+	}
+	if state.SensitiveParameters["AdminPassword"].ValueString() != "secret" || len(state.SensitiveParameters) != 1 {
+		t.Errorf("expected only the password in the sensitive parameters, got %v", state.SensitiveParameters)
+	}
+	if subscribes.Load() != 0 {
+		t.Errorf("expected no subscription, got %d", subscribes.Load())
+	}
+}
+
+func TestInstanceDataSourceDoesNotSubscribe(t *testing.T) {
+	var subscribes atomic.Int32
+	resp, _ := readInstanceDataSource(t, testOSC(&subscribes), "other", "example")
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error for a service that is not subscribed")
+	}
+	if summary := resp.Diagnostics.Errors()[0].Summary(); summary != "Service not subscribed" {
+		t.Errorf("unexpected error %q", summary)
+	}
+	if subscribes.Load() != 0 {
+		t.Errorf("expected a lookup not to subscribe the tenant, got %d subscriptions", subscribes.Load())
+	}
+}
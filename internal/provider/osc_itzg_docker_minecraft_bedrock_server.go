@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &itzgdockerminecraftbedrockserver{}
 	_ resource.ResourceWithConfigure = &itzgdockerminecraftbedrockserver{}
+	_ resource.ResourceWithImportState = &itzgdockerminecraftbedrockserver{}
+	_ resource.ResourceWithUpgradeState = &itzgdockerminecraftbedrockserver{}
 )
 
 func Newitzgdockerminecraftbedrockserver() resource.Resource {
@@ -28,7 +35,7 @@ func (r *itzgdockerminecraftbedrockserver) Configure(ctx context.Context, req re
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *itzgdockerminecraftbedrockserver) Configure(ctx context.Context, req re
 
 // itzgdockerminecraftbedrockserver is the resource implementation.
 type itzgdockerminecraftbedrockserver struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type itzgdockerminecraftbedrockserverModel struct {
@@ -52,9 +59,13 @@ type itzgdockerminecraftbedrockserverModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Gamemode         types.String       `tfsdk:"game_mode"`
-	Maxplayers         types.String       `tfsdk:"max_players"`
+	Maxplayers         types.Int64       `tfsdk:"max_players"`
 	Leveltype         types.String       `tfsdk:"level_type"`
 	Variables         types.String       `tfsdk:"variables"`
 }
@@ -64,9 +75,11 @@ func (r *itzgdockerminecraftbedrockserver) Metadata(_ context.Context, req resou
 }
 
 // Schema defines the schema for the resource.
-func (r *itzgdockerminecraftbedrockserver) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *itzgdockerminecraftbedrockserver) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Unleash the full potential of multiplayer gaming with Itzg&#39;s Minecraft Bedrock Server Docker. Effortlessly run and upgrade your server with cutting-edge game features. Your world, your rules—simplified!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Unleash the full potential of multiplayer gaming with Itzg's Minecraft Bedrock Server Docker. Effortlessly run and upgrade your server with cutting-edge game features. Your world, your rules—simplified!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -84,27 +97,86 @@ func (r *itzgdockerminecraftbedrockserver) Schema(_ context.Context, _ resource.
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of docker-minecraft-bedrock-server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"game_mode": schema.StringAttribute{
 				Optional: true,
 				Description: "Sets the game mode for the Bedrock server, controlling the gameplay experience for players",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"max_players": schema.StringAttribute{
+			"max_players": schema.Int64Attribute{
 				Optional: true,
 				Description: "Defines the maximum number of players that can connect to the server simultaneously",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"level_type": schema.StringAttribute{
 				Optional: true,
 				Description: "Specifies the type of world/level to generate for the server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"variables": schema.StringAttribute{
 				Optional: true,
 				Description: "Allows setting custom server variables as comma-separated key-value pairs or full JSON string for advanced server configuration",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -117,27 +189,55 @@ func (r *itzgdockerminecraftbedrockserver) Create(ctx context.Context, req resou
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("itzg-docker-minecraft-bedrock-server")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-bedrock-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-bedrock-server", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "itzg-docker-minecraft-bedrock-server", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"GameMode": plan.Gamemode.ValueString(),
-		"MaxPlayers": plan.Maxplayers.ValueString(),
-		"LevelType": plan.Leveltype.ValueString(),
-		"Variables": plan.Variables.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"GameMode": plan.Gamemode,
+		"MaxPlayers": plan.Maxplayers,
+		"LevelType": plan.Leveltype,
+		"Variables": plan.Variables,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "itzg-docker-minecraft-bedrock-server", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "itzg-docker-minecraft-bedrock-server", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "itzg-docker-minecraft-bedrock-server", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -152,10 +252,14 @@ func (r *itzgdockerminecraftbedrockserver) Create(ctx context.Context, req resou
 
 	// Update the state with the actual data returned from the API
 	state := itzgdockerminecraftbedrockserverModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("itzg-docker-minecraft-bedrock-server"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Gamemode: plan.Gamemode,
 		Maxplayers: plan.Maxplayers,
@@ -169,14 +273,160 @@ func (r *itzgdockerminecraftbedrockserver) Create(ctx context.Context, req resou
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "itzg-docker-minecraft-bedrock-server", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *itzgdockerminecraftbedrockserver) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state itzgdockerminecraftbedrockserverModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-bedrock-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["GameMode"].(string); ok && value != "" && state.Gamemode.IsNull() {
+			state.Gamemode = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["MaxPlayers"]); ok && state.Maxplayers.IsNull() {
+			state.Maxplayers = types.Int64Value(value)
+		}
+		if value, ok := instance["LevelType"].(string); ok && value != "" && state.Leveltype.IsNull() {
+			state.Leveltype = types.StringValue(value)
+		}
+		if value, ok := instance["Variables"].(string); ok && value != "" && state.Variables.IsNull() {
+			state.Variables = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"game_mode": state.Gamemode,
+			"max_players": state.Maxplayers,
+			"level_type": state.Leveltype,
+			"variables": state.Variables,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("itzg-docker-minecraft-bedrock-server")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *itzgdockerminecraftbedrockserver) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *itzgdockerminecraftbedrockserver) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("itzg-docker-minecraft-bedrock-server", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *itzgdockerminecraftbedrockserver) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state itzgdockerminecraftbedrockserverModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"game_mode",
+		"max_players",
+		"level_type",
+		"variables",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -188,15 +438,30 @@ func (r *itzgdockerminecraftbedrockserver) Delete(ctx context.Context, req resou
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("itzg-docker-minecraft-bedrock-server")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-bedrock-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "itzg-docker-minecraft-bedrock-server", state.Name.ValueString()))
 		return
 	}
 }
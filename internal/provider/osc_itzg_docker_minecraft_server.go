@@ -3,16 +3,24 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &itzgdockerminecraftserver{}
 	_ resource.ResourceWithConfigure = &itzgdockerminecraftserver{}
+	_ resource.ResourceWithImportState = &itzgdockerminecraftserver{}
+	_ resource.ResourceWithUpgradeState = &itzgdockerminecraftserver{}
 )
 
 func Newitzgdockerminecraftserver() resource.Resource {
@@ -28,7 +36,7 @@ func (r *itzgdockerminecraftserver) Configure(ctx context.Context, req resource.
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +52,7 @@ func (r *itzgdockerminecraftserver) Configure(ctx context.Context, req resource.
 
 // itzgdockerminecraftserver is the resource implementation.
 type itzgdockerminecraftserver struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type itzgdockerminecraftserverModel struct {
@@ -52,21 +60,25 @@ type itzgdockerminecraftserverModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
-	Accepteula         bool       `tfsdk:"accept_eula"`
+	Accepteula         types.Bool       `tfsdk:"accept_eula"`
 	Rconpassword         types.String       `tfsdk:"rcon_password"`
 	Mode         types.String       `tfsdk:"mode"`
 	Difficulty         types.String       `tfsdk:"difficulty"`
-	Maxworldsize         types.String       `tfsdk:"max_world_size"`
-	Allownether         bool       `tfsdk:"allow_nether"`
-	Announceplayerachievements         bool       `tfsdk:"announce_player_achievements"`
-	Enablecommandblock         bool       `tfsdk:"enable_command_block"`
-	Forcegamemode         bool       `tfsdk:"force_gamemode"`
-	Generalstructures         bool       `tfsdk:"general_structures"`
-	Hardcore         bool       `tfsdk:"hardcore"`
-	Spawnanimals         bool       `tfsdk:"spawn_animals"`
-	Spawnmonsters         bool       `tfsdk:"spawn_monsters"`
-	Spawnnpcs         bool       `tfsdk:"spawn_npcs"`
+	Maxworldsize         types.Int64       `tfsdk:"max_world_size"`
+	Allownether         types.Bool       `tfsdk:"allow_nether"`
+	Announceplayerachievements         types.Bool       `tfsdk:"announce_player_achievements"`
+	Enablecommandblock         types.Bool       `tfsdk:"enable_command_block"`
+	Forcegamemode         types.Bool       `tfsdk:"force_gamemode"`
+	Generalstructures         types.Bool       `tfsdk:"general_structures"`
+	Hardcore         types.Bool       `tfsdk:"hardcore"`
+	Spawnanimals         types.Bool       `tfsdk:"spawn_animals"`
+	Spawnmonsters         types.Bool       `tfsdk:"spawn_monsters"`
+	Spawnnpcs         types.Bool       `tfsdk:"spawn_npcs"`
 }
 
 func (r *itzgdockerminecraftserver) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -74,9 +86,11 @@ func (r *itzgdockerminecraftserver) Metadata(_ context.Context, req resource.Met
 }
 
 // Schema defines the schema for the resource.
-func (r *itzgdockerminecraftserver) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *itzgdockerminecraftserver) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Experience seamless Minecraft server management with our Docker solution! Easily deploy, customize, and scale your servers with robust support for different versions, mods, and plugins. Perfect for dedicated gamers and server admins alike!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Experience seamless Minecraft server management with our Docker solution! Easily deploy, customize, and scale your servers with robust support for different versions, mods, and plugins. Perfect for dedicated gamers and server admins alike!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -94,67 +108,163 @@ func (r *itzgdockerminecraftserver) Schema(_ context.Context, _ resource.SchemaR
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of docker-minecraft-server",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"accept_eula": schema.BoolAttribute{
 				Required: true,
 				Description: "Accepts the Minecraft End User License Agreement (EULA). Must be set to true to run the server legally.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"rcon_password": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "Sets the password for RCON (Remote Console) access to the server, allowing remote administration and command execution.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"mode": schema.StringAttribute{
 				Required: true,
-				Description: "Sets the game mode for the server (survival, creative, adventure, or spectator).",
+				Description: "Sets the game mode for the server (survival, creative, adventure, or spectator). Allowed values: survival, creative, adventure, spectator.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("survival", "creative", "adventure", "spectator"),
+				},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"difficulty": schema.StringAttribute{
 				Optional: true,
-				Description: "Sets the difficulty level of the server (peaceful, easy, normal, or hard).",
+				Description: "Sets the difficulty level of the server (peaceful, easy, normal, or hard). Allowed values: peaceful, easy, normal, hard.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("peaceful", "easy", "normal", "hard"),
+				},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"max_world_size": schema.StringAttribute{
+			"max_world_size": schema.Int64Attribute{
 				Optional: true,
 				Description: "Sets the maximum radius of the world border in blocks. Players cannot move beyond this boundary.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"allow_nether": schema.BoolAttribute{
 				Optional: true,
 				Description: "Enables or disables access to the Nether dimension.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"announce_player_achievements": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether player achievements are announced to all players on the server.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"enable_command_block": schema.BoolAttribute{
 				Optional: true,
 				Description: "Enables or disables command blocks on the server.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"force_gamemode": schema.BoolAttribute{
 				Optional: true,
 				Description: "Forces players to join in the default game mode and prevents them from changing it.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"general_structures": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether structures like villages, dungeons, and other generated structures appear in the world.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"hardcore": schema.BoolAttribute{
 				Optional: true,
 				Description: "Enables hardcore mode where players are banned from the server when they die.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"spawn_animals": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether passive animals (cows, sheep, chickens, etc.) spawn naturally in the world.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"spawn_monsters": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether hostile monsters (zombies, creepers, skeletons, etc.) spawn naturally in the world.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"spawn_npcs": schema.BoolAttribute{
 				Optional: true,
 				Description: "Controls whether NPCs like villagers spawn naturally in the world.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -167,19 +277,28 @@ func (r *itzgdockerminecraftserver) Create(ctx context.Context, req resource.Cre
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("itzg-docker-minecraft-server")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-server", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "itzg-docker-minecraft-server", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
 		"AcceptEula": plan.Accepteula,
-		"RconPassword": plan.Rconpassword.ValueString(),
+		"RconPassword": plan.Rconpassword,
 		"Mode": plan.Mode,
 		"Difficulty": plan.Difficulty,
-		"MaxWorldSize": plan.Maxworldsize.ValueString(),
+		"MaxWorldSize": plan.Maxworldsize,
 		"AllowNether": plan.Allownether,
 		"AnnouncePlayerAchievements": plan.Announceplayerachievements,
 		"EnableCommandBlock": plan.Enablecommandblock,
@@ -190,14 +309,33 @@ func (r *itzgdockerminecraftserver) Create(ctx context.Context, req resource.Cre
 		"SpawnMonsters": plan.Spawnmonsters,
 		"SpawnNpcs": plan.Spawnnpcs,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "itzg-docker-minecraft-server", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "itzg-docker-minecraft-server", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "itzg-docker-minecraft-server", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "itzg-docker-minecraft-server", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "itzg-docker-minecraft-server", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -212,10 +350,14 @@ func (r *itzgdockerminecraftserver) Create(ctx context.Context, req resource.Cre
 
 	// Update the state with the actual data returned from the API
 	state := itzgdockerminecraftserverModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("itzg-docker-minecraft-server"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Accepteula: plan.Accepteula,
 		Rconpassword: plan.Rconpassword,
@@ -239,14 +381,207 @@ func (r *itzgdockerminecraftserver) Create(ctx context.Context, req resource.Cre
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "itzg-docker-minecraft-server", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "itzg-docker-minecraft-server", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *itzgdockerminecraftserver) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state itzgdockerminecraftserverModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "itzg-docker-minecraft-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "itzg-docker-minecraft-server", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["AcceptEula"].(bool); ok && state.Accepteula.IsNull() {
+			state.Accepteula = types.BoolValue(value)
+		}
+		if value, ok := instance["Mode"].(string); ok && value != "" && state.Mode.IsNull() {
+			state.Mode = types.StringValue(value)
+		}
+		if value, ok := instance["Difficulty"].(string); ok && value != "" && state.Difficulty.IsNull() {
+			state.Difficulty = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["MaxWorldSize"]); ok && state.Maxworldsize.IsNull() {
+			state.Maxworldsize = types.Int64Value(value)
+		}
+		if value, ok := instance["AllowNether"].(bool); ok && state.Allownether.IsNull() {
+			state.Allownether = types.BoolValue(value)
+		}
+		if value, ok := instance["AnnouncePlayerAchievements"].(bool); ok && state.Announceplayerachievements.IsNull() {
+			state.Announceplayerachievements = types.BoolValue(value)
+		}
+		if value, ok := instance["EnableCommandBlock"].(bool); ok && state.Enablecommandblock.IsNull() {
+			state.Enablecommandblock = types.BoolValue(value)
+		}
+		if value, ok := instance["ForceGamemode"].(bool); ok && state.Forcegamemode.IsNull() {
+			state.Forcegamemode = types.BoolValue(value)
+		}
+		if value, ok := instance["GeneralStructures"].(bool); ok && state.Generalstructures.IsNull() {
+			state.Generalstructures = types.BoolValue(value)
+		}
+		if value, ok := instance["Hardcore"].(bool); ok && state.Hardcore.IsNull() {
+			state.Hardcore = types.BoolValue(value)
+		}
+		if value, ok := instance["SpawnAnimals"].(bool); ok && state.Spawnanimals.IsNull() {
+			state.Spawnanimals = types.BoolValue(value)
+		}
+		if value, ok := instance["SpawnMonsters"].(bool); ok && state.Spawnmonsters.IsNull() {
+			state.Spawnmonsters = types.BoolValue(value)
+		}
+		if value, ok := instance["SpawnNpcs"].(bool); ok && state.Spawnnpcs.IsNull() {
+			state.Spawnnpcs = types.BoolValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"accept_eula": state.Accepteula,
+			"rcon_password": state.Rconpassword,
+			"mode": state.Mode,
+			"difficulty": state.Difficulty,
+			"max_world_size": state.Maxworldsize,
+			"allow_nether": state.Allownether,
+			"announce_player_achievements": state.Announceplayerachievements,
+			"enable_command_block": state.Enablecommandblock,
+			"force_gamemode": state.Forcegamemode,
+			"general_structures": state.Generalstructures,
+			"hardcore": state.Hardcore,
+			"spawn_animals": state.Spawnanimals,
+			"spawn_monsters": state.Spawnmonsters,
+			"spawn_npcs": state.Spawnnpcs,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("itzg-docker-minecraft-server")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *itzgdockerminecraftserver) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *itzgdockerminecraftserver) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("itzg-docker-minecraft-server", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *itzgdockerminecraftserver) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state itzgdockerminecraftserverModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"accept_eula",
+		"rcon_password",
+		"mode",
+		"difficulty",
+		"max_world_size",
+		"allow_nether",
+		"announce_player_achievements",
+		"enable_command_block",
+		"force_gamemode",
+		"general_structures",
+		"hardcore",
+		"spawn_animals",
+		"spawn_monsters",
+		"spawn_npcs",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -258,15 +593,30 @@ func (r *itzgdockerminecraftserver) Delete(ctx context.Context, req resource.Del
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("itzg-docker-minecraft-server")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "itzg-docker-minecraft-server"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "itzg-docker-minecraft-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "itzg-docker-minecraft-server", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "itzg-docker-minecraft-server", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "itzg-docker-minecraft-server", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &mpociotclaudecodeslackbot{}
 	_ resource.ResourceWithConfigure = &mpociotclaudecodeslackbot{}
+	_ resource.ResourceWithImportState = &mpociotclaudecodeslackbot{}
+	_ resource.ResourceWithUpgradeState = &mpociotclaudecodeslackbot{}
 )
 
 func Newmpociotclaudecodeslackbot() resource.Resource {
@@ -28,7 +33,7 @@ func (r *mpociotclaudecodeslackbot) Configure(ctx context.Context, req resource.
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *mpociotclaudecodeslackbot) Configure(ctx context.Context, req resource.
 
 // mpociotclaudecodeslackbot is the resource implementation.
 type mpociotclaudecodeslackbot struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type mpociotclaudecodeslackbotModel struct {
@@ -52,6 +57,10 @@ type mpociotclaudecodeslackbotModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Slackbottoken         types.String       `tfsdk:"slack_bot_token"`
 	Slackapptoken         types.String       `tfsdk:"slack_app_token"`
@@ -68,9 +77,11 @@ func (r *mpociotclaudecodeslackbot) Metadata(_ context.Context, req resource.Met
 }
 
 // Schema defines the schema for the resource.
-func (r *mpociotclaudecodeslackbot) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *mpociotclaudecodeslackbot) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Boost productivity with Claude Code Slack Bot! Get AI-driven coding assistance right in Slack. Chat in threads, stream responses, and keep context seamlessly across messages. Transform your coding workflow today!`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Boost productivity with Claude Code Slack Bot! Get AI-driven coding assistance right in Slack. Chat in threads, stream responses, and keep context seamlessly across messages. Transform your coding workflow today!",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -88,43 +99,117 @@ func (r *mpociotclaudecodeslackbot) Schema(_ context.Context, _ resource.SchemaR
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of claude-code-slack-bot",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_bot_token": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_app_token": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"slack_signing_secret": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"anthropic_api_key": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"github_app_id": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"github_private_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"github_installation_id": schema.StringAttribute{
 				Optional: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"github_token": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -137,31 +222,59 @@ func (r *mpociotclaudecodeslackbot) Create(ctx context.Context, req resource.Cre
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("mpociot-claude-code-slack-bot")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "mpociot-claude-code-slack-bot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "mpociot-claude-code-slack-bot", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "mpociot-claude-code-slack-bot", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"SlackBotToken": plan.Slackbottoken.ValueString(),
-		"SlackAppToken": plan.Slackapptoken.ValueString(),
-		"SlackSigningSecret": plan.Slacksigningsecret.ValueString(),
-		"AnthropicApiKey": plan.Anthropicapikey.ValueString(),
-		"GithubAppId": plan.Githubappid.ValueString(),
-		"GithubPrivateKey": plan.Githubprivatekey.ValueString(),
-		"GithubInstallationId": plan.Githubinstallationid.ValueString(),
-		"GithubToken": plan.Githubtoken.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"SlackBotToken": plan.Slackbottoken,
+		"SlackAppToken": plan.Slackapptoken,
+		"SlackSigningSecret": plan.Slacksigningsecret,
+		"AnthropicApiKey": plan.Anthropicapikey,
+		"GithubAppId": plan.Githubappid,
+		"GithubPrivateKey": plan.Githubprivatekey,
+		"GithubInstallationId": plan.Githubinstallationid,
+		"GithubToken": plan.Githubtoken,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "mpociot-claude-code-slack-bot", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "mpociot-claude-code-slack-bot", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "mpociot-claude-code-slack-bot", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -176,10 +289,14 @@ func (r *mpociotclaudecodeslackbot) Create(ctx context.Context, req resource.Cre
 
 	// Update the state with the actual data returned from the API
 	state := mpociotclaudecodeslackbotModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("mpociot-claude-code-slack-bot"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Slackbottoken: plan.Slackbottoken,
 		Slackapptoken: plan.Slackapptoken,
@@ -197,14 +314,162 @@ func (r *mpociotclaudecodeslackbot) Create(ctx context.Context, req resource.Cre
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "mpociot-claude-code-slack-bot", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *mpociotclaudecodeslackbot) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state mpociotclaudecodeslackbotModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "mpociot-claude-code-slack-bot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["GithubAppId"].(string); ok && value != "" && state.Githubappid.IsNull() {
+			state.Githubappid = types.StringValue(value)
+		}
+		if value, ok := instance["GithubInstallationId"].(string); ok && value != "" && state.Githubinstallationid.IsNull() {
+			state.Githubinstallationid = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"slack_bot_token": state.Slackbottoken,
+			"slack_app_token": state.Slackapptoken,
+			"slack_signing_secret": state.Slacksigningsecret,
+			"anthropic_api_key": state.Anthropicapikey,
+			"github_app_id": state.Githubappid,
+			"github_private_key": state.Githubprivatekey,
+			"github_installation_id": state.Githubinstallationid,
+			"github_token": state.Githubtoken,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("mpociot-claude-code-slack-bot")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *mpociotclaudecodeslackbot) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *mpociotclaudecodeslackbot) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("mpociot-claude-code-slack-bot", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *mpociotclaudecodeslackbot) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state mpociotclaudecodeslackbotModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"slack_bot_token",
+		"slack_app_token",
+		"slack_signing_secret",
+		"anthropic_api_key",
+		"github_app_id",
+		"github_private_key",
+		"github_installation_id",
+		"github_token",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -216,15 +481,30 @@ func (r *mpociotclaudecodeslackbot) Delete(ctx context.Context, req resource.Del
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("mpociot-claude-code-slack-bot")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "mpociot-claude-code-slack-bot"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "mpociot-claude-code-slack-bot", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "mpociot-claude-code-slack-bot", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "mpociot-claude-code-slack-bot", state.Name.ValueString()))
 		return
 	}
 }
@@ -3,16 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 )
 
 var (
 	_ resource.Resource              = &rybbitiorybbit{}
 	_ resource.ResourceWithConfigure = &rybbitiorybbit{}
+	_ resource.ResourceWithImportState = &rybbitiorybbit{}
+	_ resource.ResourceWithUpgradeState = &rybbitiorybbit{}
 )
 
 func Newrybbitiorybbit() resource.Resource {
@@ -28,7 +35,7 @@ func (r *rybbitiorybbit) Configure(ctx context.Context, req resource.ConfigureRe
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +51,7 @@ func (r *rybbitiorybbit) Configure(ctx context.Context, req resource.ConfigureRe
 
 // rybbitiorybbit is the resource implementation.
 type rybbitiorybbit struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type rybbitiorybbitModel struct {
@@ -52,9 +59,13 @@ type rybbitiorybbitModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Postgreshost         types.String       `tfsdk:"postgres_host"`
-	Postgresport         types.String       `tfsdk:"postgres_port"`
+	Postgresport         types.Int64       `tfsdk:"postgres_port"`
 	Postgresuser         types.String       `tfsdk:"postgres_user"`
 	Postgrespassword         types.String       `tfsdk:"postgres_password"`
 	Postgresdb         types.String       `tfsdk:"postgres_db"`
@@ -63,9 +74,9 @@ type rybbitiorybbitModel struct {
 	Clickhousepassword         types.String       `tfsdk:"clickhouse_password"`
 	Betterauthsecret         types.String       `tfsdk:"better_auth_secret"`
 	Redishost         types.String       `tfsdk:"redis_host"`
-	Redisport         types.String       `tfsdk:"redis_port"`
+	Redisport         types.Int64       `tfsdk:"redis_port"`
 	Redispassword         types.String       `tfsdk:"redis_password"`
-	Disablesignup         bool       `tfsdk:"disable_signup"`
+	Disablesignup         types.Bool       `tfsdk:"disable_signup"`
 	Mapboxtoken         types.String       `tfsdk:"mapbox_token"`
 	Resendapikey         types.String       `tfsdk:"resend_api_key"`
 }
@@ -75,9 +86,11 @@ func (r *rybbitiorybbit) Metadata(_ context.Context, req resource.MetadataReques
 }
 
 // Schema defines the schema for the resource.
-func (r *rybbitiorybbit) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *rybbitiorybbit) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Elevate your web analytics with Rybbit! This open-source, privacy-friendly alternative to Google Analytics is easy to set up and use. Gain insights with advanced features like session replays and real-time dashboards.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Elevate your web analytics with Rybbit! This open-source, privacy-friendly alternative to Google Analytics is easy to set up and use. Gain insights with advanced features like session replays and real-time dashboards.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -95,71 +108,172 @@ func (r *rybbitiorybbit) Schema(_ context.Context, _ resource.SchemaRequest, res
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of rybbit",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"postgres_host": schema.StringAttribute{
 				Required: true,
 				Description: "The hostname or IP address of your PostgreSQL database server. PostgreSQL is used by Rybbit to store user accounts, site configurations, organization settings, and other application metadata.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"postgres_port": schema.StringAttribute{
+			"postgres_port": schema.Int64Attribute{
 				Optional: true,
 				Description: "The port number on which your PostgreSQL database server is listening. If not specified, the default PostgreSQL port (5432) will be used.",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"postgres_user": schema.StringAttribute{
 				Required: true,
 				Description: "The username for authenticating with your PostgreSQL database. This user must have the necessary permissions to create, read, update, and delete data in the specified database.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"postgres_password": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "The password for authenticating with your PostgreSQL database using the specified username.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"postgres_db": schema.StringAttribute{
 				Required: true,
 				Description: "The name of the PostgreSQL database that Rybbit will use to store its application data. This database will contain tables for users, sites, organizations, and other metadata.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"clickhouse_host": schema.StringAttribute{
 				Required: true,
 				Description: "The hostname or IP address of your ClickHouse database server. ClickHouse is used by Rybbit to store and analyze high-volume analytics data including pageviews, events, sessions, and user interactions.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"clickhouse_db": schema.StringAttribute{
 				Optional: true,
 				Description: "The name of the ClickHouse database that Rybbit will use for storing analytics data. If not specified, a default database name will be used.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"clickhouse_password": schema.StringAttribute{
 				Required: true,
+				Sensitive: true,
 				Description: "The password for authenticating with your ClickHouse database server. This is required for secure access to the analytics database.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"better_auth_secret": schema.StringAttribute{
 				Required: true,
-				Description: "A secret key used by Rybbit&#39;s authentication system to encrypt and sign tokens, sessions, and other security-related data. This should be a long, random string.",
+				Sensitive: true,
+				Description: "A secret key used by Rybbit's authentication system to encrypt and sign tokens, sessions, and other security-related data. This should be a long, random string.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"redis_host": schema.StringAttribute{
 				Optional: true,
 				Description: "The hostname or IP address of your Redis server. Redis is used by Rybbit for caching, session storage, and improving application performance.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
-			"redis_port": schema.StringAttribute{
+			"redis_port": schema.Int64Attribute{
 				Optional: true,
 				Description: "The port number on which your Redis server is listening. If not specified, the default Redis port (6379) will be used.",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceInt64(),
+				},
 			},
 			"redis_password": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "The password for authenticating with your Redis server, if authentication is enabled on your Redis instance.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"disable_signup": schema.BoolAttribute{
 				Optional: true,
 				Description: "When set to true, prevents new users from creating accounts through the signup process. Useful for private installations where you want to control user access.",
+				PlanModifiers: []planmodifier.Bool{
+					requiresReplaceBool(),
+				},
 			},
 			"mapbox_token": schema.StringAttribute{
 				Optional: true,
-				Description: "Your Mapbox API token for enabling advanced map visualizations in Rybbit&#39;s analytics dashboard. Required for the geographic analytics features including the interactive globe and detailed location maps.",
+				Sensitive: true,
+				Description: "Your Mapbox API token for enabling advanced map visualizations in Rybbit's analytics dashboard. Required for the geographic analytics features including the interactive globe and detailed location maps.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"resend_api_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Your Resend API key for sending transactional emails such as password resets, account invitations, and other notifications from your Rybbit installation.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -172,38 +286,66 @@ func (r *rybbitiorybbit) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("rybbit-io-rybbit")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "rybbit-io-rybbit"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "rybbit-io-rybbit", plan.Name.ValueString()))
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "rybbit-io-rybbit", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"PostgresHost": plan.Postgreshost.ValueString(),
-		"PostgresPort": plan.Postgresport.ValueString(),
-		"PostgresUser": plan.Postgresuser.ValueString(),
-		"PostgresPassword": plan.Postgrespassword.ValueString(),
-		"PostgresDb": plan.Postgresdb.ValueString(),
-		"ClickhouseHost": plan.Clickhousehost.ValueString(),
-		"ClickhouseDb": plan.Clickhousedb.ValueString(),
-		"ClickhousePassword": plan.Clickhousepassword.ValueString(),
-		"BetterAuthSecret": plan.Betterauthsecret.ValueString(),
-		"RedisHost": plan.Redishost.ValueString(),
-		"RedisPort": plan.Redisport.ValueString(),
-		"RedisPassword": plan.Redispassword.ValueString(),
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"PostgresHost": plan.Postgreshost,
+		"PostgresPort": plan.Postgresport,
+		"PostgresUser": plan.Postgresuser,
+		"PostgresPassword": plan.Postgrespassword,
+		"PostgresDb": plan.Postgresdb,
+		"ClickhouseHost": plan.Clickhousehost,
+		"ClickhouseDb": plan.Clickhousedb,
+		"ClickhousePassword": plan.Clickhousepassword,
+		"BetterAuthSecret": plan.Betterauthsecret,
+		"RedisHost": plan.Redishost,
+		"RedisPort": plan.Redisport,
+		"RedisPassword": plan.Redispassword,
 		"DisableSignup": plan.Disablesignup,
-		"MapboxToken": plan.Mapboxtoken.ValueString(),
-		"ResendApiKey": plan.Resendapikey.ValueString(),
+		"MapboxToken": plan.Mapboxtoken,
+		"ResendApiKey": plan.Resendapikey,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "rybbit-io-rybbit", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "rybbit-io-rybbit", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "rybbit-io-rybbit", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "rybbit-io-rybbit", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "rybbit-io-rybbit", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -218,10 +360,14 @@ func (r *rybbitiorybbit) Create(ctx context.Context, req resource.CreateRequest,
 
 	// Update the state with the actual data returned from the API
 	state := rybbitiorybbitModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("rybbit-io-rybbit"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Postgreshost: plan.Postgreshost,
 		Postgresport: plan.Postgresport,
@@ -246,14 +392,197 @@ func (r *rybbitiorybbit) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "rybbit-io-rybbit", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "rybbit-io-rybbit", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *rybbitiorybbit) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rybbitiorybbitModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "rybbit-io-rybbit"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "rybbit-io-rybbit", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "rybbit-io-rybbit", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["PostgresHost"].(string); ok && value != "" && state.Postgreshost.IsNull() {
+			state.Postgreshost = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["PostgresPort"]); ok && state.Postgresport.IsNull() {
+			state.Postgresport = types.Int64Value(value)
+		}
+		if value, ok := instance["PostgresUser"].(string); ok && value != "" && state.Postgresuser.IsNull() {
+			state.Postgresuser = types.StringValue(value)
+		}
+		if value, ok := instance["PostgresDb"].(string); ok && value != "" && state.Postgresdb.IsNull() {
+			state.Postgresdb = types.StringValue(value)
+		}
+		if value, ok := instance["ClickhouseHost"].(string); ok && value != "" && state.Clickhousehost.IsNull() {
+			state.Clickhousehost = types.StringValue(value)
+		}
+		if value, ok := instance["ClickhouseDb"].(string); ok && value != "" && state.Clickhousedb.IsNull() {
+			state.Clickhousedb = types.StringValue(value)
+		}
+		if value, ok := instance["RedisHost"].(string); ok && value != "" && state.Redishost.IsNull() {
+			state.Redishost = types.StringValue(value)
+		}
+		if value, ok := int64FromParameter(instance["RedisPort"]); ok && state.Redisport.IsNull() {
+			state.Redisport = types.Int64Value(value)
+		}
+		if value, ok := instance["DisableSignup"].(bool); ok && state.Disablesignup.IsNull() {
+			state.Disablesignup = types.BoolValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"postgres_host": state.Postgreshost,
+			"postgres_port": state.Postgresport,
+			"postgres_user": state.Postgresuser,
+			"postgres_password": state.Postgrespassword,
+			"postgres_db": state.Postgresdb,
+			"clickhouse_host": state.Clickhousehost,
+			"clickhouse_db": state.Clickhousedb,
+			"clickhouse_password": state.Clickhousepassword,
+			"better_auth_secret": state.Betterauthsecret,
+			"redis_host": state.Redishost,
+			"redis_port": state.Redisport,
+			"redis_password": state.Redispassword,
+			"disable_signup": state.Disablesignup,
+			"mapbox_token": state.Mapboxtoken,
+			"resend_api_key": state.Resendapikey,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("rybbit-io-rybbit")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *rybbitiorybbit) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *rybbitiorybbit) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("rybbit-io-rybbit", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *rybbitiorybbit) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state rybbitiorybbitModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"postgres_host",
+		"postgres_port",
+		"postgres_user",
+		"postgres_password",
+		"postgres_db",
+		"clickhouse_host",
+		"clickhouse_db",
+		"clickhouse_password",
+		"better_auth_secret",
+		"redis_host",
+		"redis_port",
+		"redis_password",
+		"disable_signup",
+		"mapbox_token",
+		"resend_api_key",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -265,15 +594,30 @@ func (r *rybbitiorybbit) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("rybbit-io-rybbit")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "rybbit-io-rybbit"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "rybbit-io-rybbit", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "rybbit-io-rybbit", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "rybbit-io-rybbit", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "rybbit-io-rybbit", state.Name.ValueString()))
 		return
 	}
 }
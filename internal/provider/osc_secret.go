@@ -7,8 +7,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -31,7 +29,7 @@ func (r *SecretResource) Configure(ctx context.Context, req resource.ConfigureRe
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -47,7 +45,7 @@ func (r *SecretResource) Configure(ctx context.Context, req resource.ConfigureRe
 
 // SecretResource is the resource implementation.
 type SecretResource struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type SecretResourceModel struct {
@@ -78,6 +76,7 @@ func (r *SecretResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 			},
 			"secret_value": schema.StringAttribute{
 				Required:    true,
+				Sensitive:   true,
 				Description: "Secret Value",
 			},
 			"ref": schema.StringAttribute{
@@ -99,7 +98,7 @@ func (r *SecretResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	for _, serviceId := range plan.ServiceIds {
-		err := osaasclient.AddServiceSecret(r.osaasContext, serviceId.ValueString(), plan.SecretName.ValueString(), plan.SecretValue.ValueString())
+		err := addServiceSecret(ctx, r.osaasContext, serviceId.ValueString(), plan.SecretName.ValueString(), plan.SecretValue.ValueString())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Error creating secret",
@@ -143,7 +142,7 @@ func (r *SecretResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 
 	for _, serviceId := range plan.ServiceIds {
-		err := osaasclient.DeleteServiceSecret(r.osaasContext, serviceId.ValueString(), plan.SecretName.ValueString())
+		err := deleteServiceSecret(ctx, r.osaasContext, serviceId.ValueString(), plan.SecretName.ValueString())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Error deleting secret",
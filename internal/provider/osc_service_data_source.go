@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &ServiceDataSource{}
+	_ datasource.DataSourceWithConfigure = &ServiceDataSource{}
+)
+
+func init() {
+	RegisteredDataSources = append(RegisteredDataSources, NewServiceDataSource)
+}
+
+// NewServiceDataSource is a helper function to simplify the provider implementation.
+func NewServiceDataSource() datasource.DataSource {
+	return &ServiceDataSource{}
+}
+
+func (d *ServiceDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	osaasContext, ok := req.ProviderData.(*oscClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *OscClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.osaasContext = osaasContext
+}
+
+// ServiceDataSource is the data source implementation.
+type ServiceDataSource struct {
+	osaasContext *oscClient
+}
+
+type ServiceDataSourceModel struct {
+	ServiceId         types.String                 `tfsdk:"service_id"`
+	Title             types.String                 `tfsdk:"title"`
+	Description       types.String                 `tfsdk:"description"`
+	Status            types.String                 `tfsdk:"status"`
+	ServiceType       types.String                 `tfsdk:"service_type"`
+	Category          types.String                 `tfsdk:"category"`
+	DocumentationUrl  types.String                 `tfsdk:"documentation_url"`
+	RepoUrl           types.String                 `tfsdk:"repo_url"`
+	OpenSourceLicense types.String                 `tfsdk:"open_source_license"`
+	InstanceOptions   []ServiceInstanceOptionModel `tfsdk:"instance_options"`
+}
+
+type ServiceInstanceOptionModel struct {
+	Name        types.String   `tfsdk:"name"`
+	Label       types.String   `tfsdk:"label"`
+	Type        types.String   `tfsdk:"type"`
+	Mandatory   types.Bool     `tfsdk:"mandatory"`
+	Description types.String   `tfsdk:"description"`
+	Enums       []types.String `tfsdk:"enums"`
+}
+
+// Metadata returns the data source type name.
+func (d *ServiceDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service"
+}
+
+// Schema defines the schema for the data source.
+func (d *ServiceDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Look up a service in the OSC catalog",
+		Attributes: map[string]schema.Attribute{
+			"service_id": schema.StringAttribute{
+				Required:    true,
+				Description: "The service id, e.g. 'valkey-io-valkey'",
+			},
+			"title": schema.StringAttribute{
+				Computed:    true,
+				Description: "The service title",
+			},
+			"description": schema.StringAttribute{
+				Computed:    true,
+				Description: "The service description",
+			},
+			"status": schema.StringAttribute{
+				Computed:    true,
+				Description: "The catalog status of the service, e.g. 'PUBLISHED'",
+			},
+			"service_type": schema.StringAttribute{
+				Computed:    true,
+				Description: "The service type",
+			},
+			"category": schema.StringAttribute{
+				Computed:    true,
+				Description: "The service category",
+			},
+			"documentation_url": schema.StringAttribute{
+				Computed:    true,
+				Description: "URL to the service documentation",
+			},
+			"repo_url": schema.StringAttribute{
+				Computed:    true,
+				Description: "URL to the service source repository",
+			},
+			"open_source_license": schema.StringAttribute{
+				Computed:    true,
+				Description: "The open source license of the service",
+			},
+			"instance_options": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The options accepted when creating an instance of the service",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "The option name as sent to the service",
+						},
+						"label": schema.StringAttribute{
+							Computed:    true,
+							Description: "The option label",
+						},
+						"type": schema.StringAttribute{
+							Computed:    true,
+							Description: "The option type, e.g. 'string', 'boolean', 'enum' or 'list'",
+						},
+						"mandatory": schema.BoolAttribute{
+							Computed:    true,
+							Description: "Whether the option must be set",
+						},
+						"description": schema.StringAttribute{
+							Computed:    true,
+							Description: "The option description",
+						},
+						"enums": schema.ListAttribute{
+							ElementType: types.StringType,
+							Computed:    true,
+							Description: "The allowed values of an enum option",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *ServiceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config ServiceDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	service, err := getCatalogService(ctx, d.osaasContext, config.ServiceId.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get service", err.Error())
+		return
+	}
+	if service == nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("service_id"),
+			"Service not found",
+			fmt.Sprintf("The OSC catalog has no service with id %q.", config.ServiceId.ValueString()),
+		)
+		return
+	}
+
+	state := ServiceDataSourceModel{
+		ServiceId:         types.StringValue(service.ServiceId),
+		Title:             types.StringValue(service.Metadata.Title),
+		Description:       types.StringValue(service.Metadata.Description),
+		Status:            types.StringValue(service.Status),
+		ServiceType:       types.StringValue(service.ServiceType),
+		Category:          types.StringValue(service.Metadata.Category),
+		DocumentationUrl:  types.StringValue(service.Metadata.DocumentationUrl),
+		RepoUrl:           types.StringValue(service.Metadata.RepoUrl),
+		OpenSourceLicense: types.StringValue(service.OpenSourceLicense),
+		InstanceOptions:   []ServiceInstanceOptionModel{},
+	}
+	for _, option := range service.ServiceInstanceOptions {
+		enums := []types.String{}
+		for _, enum := range option.Enum {
+			enums = append(enums, types.StringValue(enum))
+		}
+		state.InstanceOptions = append(state.InstanceOptions, ServiceInstanceOptionModel{
+			Name:        types.StringValue(option.Name),
+			Label:       types.StringValue(option.Label),
+			Type:        types.StringValue(option.Type),
+			Mandatory:   types.BoolValue(option.Mandatory),
+			Description: types.StringValue(option.Description),
+			Enums:       enums,
+		})
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &ServicesDataSource{}
+	_ datasource.DataSourceWithConfigure = &ServicesDataSource{}
+)
+
+func init() {
+	RegisteredDataSources = append(RegisteredDataSources, NewServicesDataSource)
+}
+
+// NewServicesDataSource is a helper function to simplify the provider implementation.
+func NewServicesDataSource() datasource.DataSource {
+	return &ServicesDataSource{}
+}
+
+func (d *ServicesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	osaasContext, ok := req.ProviderData.(*oscClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *OscClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.osaasContext = osaasContext
+}
+
+// ServicesDataSource is the data source implementation.
+type ServicesDataSource struct {
+	osaasContext *oscClient
+}
+
+type ServicesDataSourceModel struct {
+	Status   types.String          `tfsdk:"status"`
+	IdPrefix types.String          `tfsdk:"id_prefix"`
+	Services []ServiceSummaryModel `tfsdk:"services"`
+}
+
+type ServiceSummaryModel struct {
+	ServiceId   types.String `tfsdk:"service_id"`
+	Title       types.String `tfsdk:"title"`
+	Description types.String `tfsdk:"description"`
+	Status      types.String `tfsdk:"status"`
+	ServiceType types.String `tfsdk:"service_type"`
+	Category    types.String `tfsdk:"category"`
+}
+
+// Metadata returns the data source type name.
+func (d *ServicesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_services"
+}
+
+// Schema defines the schema for the data source.
+func (d *ServicesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "List services in the OSC catalog",
+		Attributes: map[string]schema.Attribute{
+			"status": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only include services with this status, e.g. 'PUBLISHED'",
+			},
+			"id_prefix": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only include services whose service id starts with this prefix",
+			},
+			"services": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The matching services",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"service_id": schema.StringAttribute{
+							Computed:    true,
+							Description: "The service id",
+						},
+						"title": schema.StringAttribute{
+							Computed:    true,
+							Description: "The service title",
+						},
+						"description": schema.StringAttribute{
+							Computed:    true,
+							Description: "The service description",
+						},
+						"status": schema.StringAttribute{
+							Computed:    true,
+							Description: "The catalog status of the service",
+						},
+						"service_type": schema.StringAttribute{
+							Computed:    true,
+							Description: "The service type",
+						},
+						"category": schema.StringAttribute{
+							Computed:    true,
+							Description: "The service category",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *ServicesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config ServicesDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	services, err := listServices(ctx, d.osaasContext)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to list services", err.Error())
+		return
+	}
+
+	state := ServicesDataSourceModel{
+		Status:   config.Status,
+		IdPrefix: config.IdPrefix,
+		Services: []ServiceSummaryModel{},
+	}
+	for _, service := range services {
+		if !config.Status.IsNull() && service.Status != config.Status.ValueString() {
+			continue
+		}
+		if !config.IdPrefix.IsNull() && !strings.HasPrefix(service.ServiceId, config.IdPrefix.ValueString()) {
+			continue
+		}
+		state.Services = append(state.Services, ServiceSummaryModel{
+			ServiceId:   types.StringValue(service.ServiceId),
+			Title:       types.StringValue(service.Metadata.Title),
+			Description: types.StringValue(service.Metadata.Description),
+			Status:      types.StringValue(service.Status),
+			ServiceType: types.StringValue(service.ServiceType),
+			Category:    types.StringValue(service.Metadata.Category),
+		})
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
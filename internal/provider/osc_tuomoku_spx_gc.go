@@ -3,16 +3,21 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 )
 
 var (
 	_ resource.Resource              = &tuomokuspxgc{}
 	_ resource.ResourceWithConfigure = &tuomokuspxgc{}
+	_ resource.ResourceWithImportState = &tuomokuspxgc{}
+	_ resource.ResourceWithUpgradeState = &tuomokuspxgc{}
 )
 
 func Newtuomokuspxgc() resource.Resource {
@@ -28,7 +33,7 @@ func (r *tuomokuspxgc) Configure(ctx context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +49,7 @@ func (r *tuomokuspxgc) Configure(ctx context.Context, req resource.ConfigureRequ
 
 // tuomokuspxgc is the resource implementation.
 type tuomokuspxgc struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type tuomokuspxgcModel struct {
@@ -52,6 +57,10 @@ type tuomokuspxgcModel struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	Name         types.String       `tfsdk:"name"`
 	Username         types.String       `tfsdk:"username"`
 	Password         types.String       `tfsdk:"password"`
@@ -71,9 +80,11 @@ func (r *tuomokuspxgc) Metadata(_ context.Context, req resource.MetadataRequest,
 }
 
 // Schema defines the schema for the resource.
-func (r *tuomokuspxgc) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *tuomokuspxgc) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `Elevate your live productions with SPX Graphics Controller! Seamlessly manage HTML graphics across platforms like OBS, vMix, and more. Ideal for stunning live streams and broadcasts with powerful customization.`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: "Elevate your live productions with SPX Graphics Controller! Seamlessly manage HTML graphics across platforms like OBS, vMix, and more. Ideal for stunning live streams and broadcasts with powerful customization.",
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -91,55 +102,135 @@ func (r *tuomokuspxgc) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			"name": schema.StringAttribute{
 				Required: true,
 				Description: "Name of spx-gc",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"username": schema.StringAttribute{
 				Optional: true,
 				Description: "Username for SPX authentication. If provided along with password, users will be required to login to access the application.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"password": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "Password for SPX authentication. Works in conjunction with username to enable login protection for the application.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_templates_url": schema.StringAttribute{
 				Optional: true,
 				Description: "S3 bucket URL or path for storing and retrieving HTML graphics templates used by SPX for live production graphics.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_projects_url": schema.StringAttribute{
 				Optional: true,
 				Description: "S3 bucket URL or path for storing SPX projects and rundowns data that would normally be stored in the DATAROOT folder.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_plugins_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Configures the S3 bucket URL for storing SPX plugins and extensions. Plugins provide additional functionality like custom controls and user interface panels.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_media_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Configures the S3 bucket URL for storing media assets like images, videos, and other files used by graphics templates.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_json_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Specifies the S3 bucket URL for storing and retrieving JSON data files used for data-driven graphics and external data integration.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_endpoint_url": schema.StringAttribute{
 				Optional: true,
 				Description: "Custom S3-compatible endpoint URL for accessing object storage services other than AWS S3, such as MinIO, DigitalOcean Spaces, or other S3-compatible storage providers.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_access_key_id": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "AWS access key ID for authenticating with S3 services to access templates, projects, and media assets stored in cloud storage.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_secret_access_key": schema.StringAttribute{
 				Optional: true,
+				Sensitive: true,
 				Description: "AWS secret access key for authenticating with S3 services, paired with the access key ID for secure cloud storage access.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 			"s3_region": schema.StringAttribute{
 				Optional: true,
 				Description: "AWS region identifier specifying the geographical region where the S3 buckets are located for optimal performance and compliance.",
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceString(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -152,34 +243,62 @@ func (r *tuomokuspxgc) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("tuomoku-spx-gc")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
-		return
-	}
-
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "tuomoku-spx-gc", serviceAccessToken, map[string]interface{}{
-		"name": plan.Name.ValueString(),
-		"Username": plan.Username.ValueString(),
-		"Password": plan.Password.ValueString(),
-		"S3TemplatesUrl": plan.S3templatesurl.ValueString(),
-		"S3ProjectsUrl": plan.S3projectsurl.ValueString(),
-		"S3PluginsUrl": plan.S3pluginsurl.ValueString(),
-		"S3MediaUrl": plan.S3mediaurl.ValueString(),
-		"S3JsonUrl": plan.S3jsonurl.ValueString(),
-		"S3EndpointUrl": plan.S3endpointurl.ValueString(),
-		"S3AccessKeyId": plan.S3accesskeyid.ValueString(),
-		"S3SecretAccessKey": plan.S3secretaccesskey.ValueString(),
-		"S3Region": plan.S3region.ValueString(),
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "tuomoku-spx-gc"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "tuomoku-spx-gc", plan.Name.ValueString()))
+		return
+	}
+
+	parameters := instancePayload(map[string]attr.Value{
+		"name": plan.Name,
+		"Username": plan.Username,
+		"Password": plan.Password,
+		"S3TemplatesUrl": plan.S3templatesurl,
+		"S3ProjectsUrl": plan.S3projectsurl,
+		"S3PluginsUrl": plan.S3pluginsurl,
+		"S3MediaUrl": plan.S3mediaurl,
+		"S3JsonUrl": plan.S3jsonurl,
+		"S3EndpointUrl": plan.S3endpointurl,
+		"S3AccessKeyId": plan.S3accesskeyid,
+		"S3SecretAccessKey": plan.S3secretaccesskey,
+		"S3Region": plan.S3region,
 	})
+
+	instance, err := createInstance(ctx, r.osaasContext, "tuomoku-spx-gc", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "tuomoku-spx-gc", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "tuomoku-spx-gc", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "tuomoku-spx-gc", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "tuomoku-spx-gc", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -194,10 +313,14 @@ func (r *tuomokuspxgc) Create(ctx context.Context, req resource.CreateRequest, r
 
 	// Update the state with the actual data returned from the API
 	state := tuomokuspxgcModel{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("tuomoku-spx-gc"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		Name: plan.Name,
 		Username: plan.Username,
 		Password: plan.Password,
@@ -218,14 +341,186 @@ func (r *tuomokuspxgc) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "tuomoku-spx-gc", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "tuomoku-spx-gc", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *tuomokuspxgc) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state tuomokuspxgcModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "tuomoku-spx-gc"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "tuomoku-spx-gc", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "tuomoku-spx-gc", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		if value, ok := instance["Username"].(string); ok && value != "" && state.Username.IsNull() {
+			state.Username = types.StringValue(value)
+		}
+		if value, ok := instance["S3TemplatesUrl"].(string); ok && value != "" && state.S3templatesurl.IsNull() {
+			state.S3templatesurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3ProjectsUrl"].(string); ok && value != "" && state.S3projectsurl.IsNull() {
+			state.S3projectsurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3PluginsUrl"].(string); ok && value != "" && state.S3pluginsurl.IsNull() {
+			state.S3pluginsurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3MediaUrl"].(string); ok && value != "" && state.S3mediaurl.IsNull() {
+			state.S3mediaurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3JsonUrl"].(string); ok && value != "" && state.S3jsonurl.IsNull() {
+			state.S3jsonurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3EndpointUrl"].(string); ok && value != "" && state.S3endpointurl.IsNull() {
+			state.S3endpointurl = types.StringValue(value)
+		}
+		if value, ok := instance["S3Region"].(string); ok && value != "" && state.S3region.IsNull() {
+			state.S3region = types.StringValue(value)
+		}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			"username": state.Username,
+			"password": state.Password,
+			"s3_templates_url": state.S3templatesurl,
+			"s3_projects_url": state.S3projectsurl,
+			"s3_plugins_url": state.S3pluginsurl,
+			"s3_media_url": state.S3mediaurl,
+			"s3_json_url": state.S3jsonurl,
+			"s3_endpoint_url": state.S3endpointurl,
+			"s3_access_key_id": state.S3accesskeyid,
+			"s3_secret_access_key": state.S3secretaccesskey,
+			"s3_region": state.S3region,
+		})))...)
+	}
+	state.ServiceId = types.StringValue("tuomoku-spx-gc")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *tuomokuspxgc) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *tuomokuspxgc) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("tuomoku-spx-gc", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *tuomokuspxgc) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state tuomokuspxgcModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adoptParameters(ctx, req, resp,
+		"username",
+		"password",
+		"s3_templates_url",
+		"s3_projects_url",
+		"s3_plugins_url",
+		"s3_media_url",
+		"s3_json_url",
+		"s3_endpoint_url",
+		"s3_access_key_id",
+		"s3_secret_access_key",
+		"s3_region",
+	)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -237,15 +532,30 @@ func (r *tuomokuspxgc) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("tuomoku-spx-gc")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "tuomoku-spx-gc"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "tuomoku-spx-gc", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "tuomoku-spx-gc", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "tuomoku-spx-gc", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "tuomoku-spx-gc", state.Name.ValueString()))
 		return
 	}
 }
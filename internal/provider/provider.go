@@ -2,8 +2,15 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -29,8 +36,15 @@ type oscProvider struct {
 }
 
 type oscProviderModel struct {
-	Pat         types.String `tfsdk:"pat"`
-	Environment types.String `tfsdk:"environment"`
+	Pat             types.String `tfsdk:"pat"`
+	Environment     types.String `tfsdk:"environment"`
+	Profile         types.String `tfsdk:"profile"`
+	CredentialsFile types.String `tfsdk:"credentials_file"`
+	CatalogURL      types.String `tfsdk:"catalog_url"`
+	TokenURL        types.String `tfsdk:"token_url"`
+	DeployURL       types.String `tfsdk:"deploy_url"`
+	MaxRetries      types.Int64  `tfsdk:"max_retries"`
+	MaxBackoff      types.String `tfsdk:"max_backoff"`
 }
 
 func (p *oscProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -44,12 +58,43 @@ func (p *oscProvider) Schema(ctx context.Context, req provider.SchemaRequest, re
 		Attributes: map[string]schema.Attribute{
 			"pat": schema.StringAttribute{
 				Sensitive: true,
-				Required:  true,
-				Description: "Personal Access Token to be used when communicating with the OSC API",
+				Optional:  true,
+				Description: "Personal Access Token to be used when communicating with the OSC API. Defaults to the OSC_ACCESS_TOKEN environment variable, then to the `pat` of the credentials file profile.",
 			},
 			"environment": schema.StringAttribute{
 				Optional: true,
-				Description: "Which Environment to use e.g. 'dev' or 'prod'",
+				Description: "Which Environment to use e.g. 'dev' or 'prod'. Defaults to the OSC_ENVIRONMENT environment variable, then to the `environment` of the credentials file profile, then to 'prod'.",
+			},
+			"profile": schema.StringAttribute{
+				Optional: true,
+				Description: "Profile of the credentials file to take the personal access token and environment from. Defaults to the OSC_PROFILE environment variable, then to 'default'. A missing 'default' profile or credentials file is not an error, any other profile must exist.",
+			},
+			"credentials_file": schema.StringAttribute{
+				Optional: true,
+				Description: "Path to the credentials file. Defaults to the OSC_CREDENTIALS_FILE environment variable, then to '~/.osc/credentials'.",
+			},
+			"catalog_url": schema.StringAttribute{
+				Optional: true,
+				Description: "Base URL of the OSC catalog API, e.g. for a staging or self-hosted deployment. Defaults to the OSC_CATALOG_URL environment variable, then to 'https://catalog.svc.<environment>.osaas.io'.",
+			},
+			"token_url": schema.StringAttribute{
+				Optional: true,
+				Description: "Base URL of the OSC token API. Defaults to the OSC_TOKEN_URL environment variable, then to 'https://token.svc.<environment>.osaas.io'.",
+			},
+			"deploy_url": schema.StringAttribute{
+				Optional: true,
+				Description: "Base URL of the OSC deploy API. Defaults to the OSC_DEPLOY_URL environment variable, then to 'https://deploy.svc.<environment>.osaas.io'.",
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional: true,
+				Description: "How many times to retry an OSC API request that failed on a transient error, such as a 429, 502, 503 or 504 response or a broken connection. Requests that create something are only retried when the OSC API cannot have acted on them, or, for an instance, once a lookup shows it was not created. Set to 0 to turn retries off. Defaults to the OSC_MAX_RETRIES environment variable, then to 3.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"max_backoff": schema.StringAttribute{
+				Optional: true,
+				Description: "Longest time to wait between two attempts of an OSC API request, e.g. '30s' or '2m'. The wait grows exponentially with jitter up to this ceiling, and a Retry-After response header is honoured up to it. Defaults to the OSC_MAX_BACKOFF environment variable, then to '30s'.",
 			},
 		},
 	}
@@ -60,6 +105,21 @@ func (p *oscProvider) Configure(ctx context.Context, req provider.ConfigureReque
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
 
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A value that is only known after apply, e.g. a token from another resource,
+	// cannot be used yet. Terraform versions that support it plan the resources
+	// of the provider again once it is known.
+	unknown := config.Pat.IsUnknown() || config.Environment.IsUnknown() || config.Profile.IsUnknown() || config.CredentialsFile.IsUnknown() ||
+		config.CatalogURL.IsUnknown() || config.TokenURL.IsUnknown() || config.DeployURL.IsUnknown() ||
+		config.MaxRetries.IsUnknown() || config.MaxBackoff.IsUnknown()
+	if unknown && req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &provider.Deferred{Reason: provider.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
 	if config.Pat.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("pat"),
@@ -68,14 +128,137 @@ func (p *oscProvider) Configure(ctx context.Context, req provider.ConfigureReque
 				"Either target apply the source of the value first, set the value statically in the configuration, or use the OSC_ACCESS_TOKEN environment variable.",
 		)
 	}
+	if config.Environment.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("environment"),
+			"Unknown OSC environment",
+			"The provider cannot create the OSC API client as there is an unknown configuration value for the OSC environment. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the OSC_ENVIRONMENT environment variable.",
+		)
+	}
+	if config.Profile.IsUnknown() || config.CredentialsFile.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown OSC credentials profile",
+			"The provider cannot read the OSC credentials file as there is an unknown configuration value for the profile or the credentials file. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the OSC_PROFILE and OSC_CREDENTIALS_FILE environment variables.",
+		)
+	}
+	endpoints := []struct {
+		attribute string
+		env       string
+		value     types.String
+	}{
+		{"catalog_url", "OSC_CATALOG_URL", config.CatalogURL},
+		{"token_url", "OSC_TOKEN_URL", config.TokenURL},
+		{"deploy_url", "OSC_DEPLOY_URL", config.DeployURL},
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(endpoint.attribute),
+				"Unknown OSC API URL",
+				fmt.Sprintf("The provider cannot create the OSC API client as there is an unknown configuration value for %s. "+
+					"Either target apply the source of the value first, set the value statically in the configuration, or use the %s environment variable.", endpoint.attribute, endpoint.env),
+			)
+		}
+	}
+	if config.MaxRetries.IsUnknown() || config.MaxBackoff.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown OSC retry settings",
+			"The provider cannot create the OSC API client as there is an unknown configuration value for max_retries or max_backoff. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the OSC_MAX_RETRIES and OSC_MAX_BACKOFF environment variables.",
+		)
+	}
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	pat := ""
-	if !config.Pat.IsNull() {
-		pat = config.Pat.ValueString()
+	pat := stringValueOrEnv(config.Pat, "OSC_ACCESS_TOKEN")
+	environment := stringValueOrEnv(config.Environment, "OSC_ENVIRONMENT")
+
+	// The credentials file is only required to exist when a profile other than
+	// the default one is asked for, so the same configuration works in CI with
+	// only environment variables set
+	profile := stringValueOrEnv(config.Profile, "OSC_PROFILE")
+	if profile == "" {
+		profile = defaultProfile
+	}
+	profileRequired := profile != defaultProfile
+	credentialsFile := stringValueOrEnv(config.CredentialsFile, "OSC_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = defaultCredentialsFile()
+	}
+
+	if (pat == "" || environment == "") && credentialsFile != "" {
+		credentials, err := readCredentialsProfile(credentialsFile, profile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("credentials_file"),
+				"Failed to read OSC credentials file",
+				err.Error(),
+			)
+			return
+		}
+		if credentials == nil && profileRequired {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("profile"),
+				"Unknown OSC credentials profile",
+				fmt.Sprintf("The credentials file %s has no profile named %q.", credentialsFile, profile),
+			)
+			return
+		}
+		if credentials != nil {
+			if pat == "" {
+				pat = credentials.Pat
+			}
+			if environment == "" {
+				environment = credentials.Environment
+			}
+		}
+	}
+
+	// An empty URL is left to newOscClient to default from the environment
+	endpointURLs := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		endpointURLs[i] = stringValueOrEnv(endpoint.value, endpoint.env)
+		if endpointURLs[i] == "" {
+			continue
+		}
+		parsed, err := url.Parse(endpointURLs[i])
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(endpoint.attribute),
+				"Invalid OSC API URL",
+				fmt.Sprintf("The %s %q is not an absolute http or https URL.", endpoint.attribute, endpointURLs[i]),
+			)
+		}
+	}
+
+	retry := retryPolicy{maxRetries: defaultMaxRetries, maxBackoff: defaultMaxBackoff}
+	if !config.MaxRetries.IsNull() {
+		retry.maxRetries = int(config.MaxRetries.ValueInt64())
+	} else if value := os.Getenv("OSC_MAX_RETRIES"); value != "" {
+		maxRetries, err := strconv.Atoi(value)
+		if err != nil || maxRetries < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retries"),
+				"Invalid OSC retry settings",
+				fmt.Sprintf("The OSC_MAX_RETRIES environment variable %q is not a number of at least 0.", value),
+			)
+		}
+		retry.maxRetries = maxRetries
+	}
+	if value := stringValueOrEnv(config.MaxBackoff, "OSC_MAX_BACKOFF"); value != "" {
+		maxBackoff, err := time.ParseDuration(value)
+		if err != nil || maxBackoff < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_backoff"),
+				"Invalid OSC retry settings",
+				fmt.Sprintf("The max_backoff %q is not a duration such as '30s' or '2m'.", value),
+			)
+		}
+		retry.maxBackoff = maxBackoff
 	}
 
 	if pat == "" {
@@ -83,7 +266,7 @@ func (p *oscProvider) Configure(ctx context.Context, req provider.ConfigureReque
 			path.Root("pat"),
 			"Missing OSC personal access token",
 			"The provider cannot create the OSC API client as there is an missing configuration value for the OSC personal access token. "+
-				"Set the value in the configuration, or use the OSC_PAT environment variable.",
+				"Set the value in the configuration, use the OSC_ACCESS_TOKEN environment variable, or set `pat` in a profile of the credentials file.",
 		)
 	}
 
@@ -91,22 +274,39 @@ func (p *oscProvider) Configure(ctx context.Context, req provider.ConfigureReque
 		return
 	}
 
-	environment := ""
-	if !config.Environment.IsNull() {
-		environment = config.Environment.ValueString()
-	}
-
 	osaasConfig := &osaasclient.ContextConfig{
 		PersonalAccessToken: pat,
 		Environment:         environment,
 	}
-	client, err := osaasclient.NewContext(osaasConfig)
+	osaasContext, err := osaasclient.NewContext(osaasConfig)
 
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to create OSC client", err.Error())
 		return
 	}
 
+	client := newOscClient(osaasContext, endpointURLs[0], endpointURLs[1], endpointURLs[2], retry)
+
+	// Fail here rather than halfway through an apply on a wrong or expired token
+	if err := validatePersonalAccessToken(ctx, client); err != nil {
+		tenant := personalAccessTokenTenant(pat)
+		if isUnauthorized(err) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("pat"),
+				"Invalid OSC personal access token",
+				fmt.Sprintf("The OSC API rejected the personal access token for tenant %s in environment %q. "+
+					"Check that the token belongs to this environment and has not expired%s.", tenant, client.GetEnvironment(), personalAccessTokenExpiry(pat)),
+			)
+		} else {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("pat"),
+				"Failed to validate OSC personal access token",
+				fmt.Sprintf("Could not validate the personal access token for tenant %s in environment %q: %s", tenant, client.GetEnvironment(), err.Error()),
+			)
+		}
+		return
+	}
+
 	resp.DataSourceData = client
 	resp.ResourceData = client
 
@@ -117,6 +317,16 @@ func (p *oscProvider) Resources(ctx context.Context) []func() resource.Resource
 	return RegisteredResources
 }
 
+var RegisteredDataSources []func() datasource.DataSource
 func (p *oscProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return nil
+	return RegisteredDataSources
+}
+
+// stringValueOrEnv returns the configured value of an attribute, or the value
+// of the environment variable if the attribute is not set.
+func stringValueOrEnv(value types.String, env string) string {
+	if !value.IsNull() && value.ValueString() != "" {
+		return value.ValueString()
+	}
+	return os.Getenv(env)
 }
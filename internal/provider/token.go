@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+const (
+	// tokenRefreshMargin is how long before its expiry a service access token
+	// is replaced, so that it does not expire halfway through a request.
+	tokenRefreshMargin = time.Minute
+	// defaultTokenLifetime is how long a service access token is kept when
+	// its expiry is unknown.
+	defaultTokenLifetime = 10 * time.Minute
+)
+
+type serviceToken struct {
+	token  string
+	expiry time.Time
+}
+
+// tokenRequest is a fetch of a service access token in progress, which
+// concurrent callers wait for instead of fetching one themselves.
+type tokenRequest struct {
+	done  chan struct{}
+	token serviceToken
+	err   error
+}
+
+// tokenCache keeps one service access token per service, shared by all
+// resources and data sources of the provider. It is safe for concurrent use.
+type tokenCache struct {
+	mu      sync.Mutex
+	tokens  map[string]serviceToken
+	pending map[string]*tokenRequest
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		tokens:  map[string]serviceToken{},
+		pending: map[string]*tokenRequest{},
+	}
+}
+
+// get returns the cached token of a service, or fetches a new one when there
+// is none or it is about to expire. Only one fetch per service runs at a time.
+func (c *tokenCache) get(ctx context.Context, serviceId string, fetch func(context.Context) (serviceToken, error)) (string, error) {
+	for {
+		c.mu.Lock()
+		if cached, ok := c.tokens[serviceId]; ok && time.Now().Add(tokenRefreshMargin).Before(cached.expiry) {
+			c.mu.Unlock()
+			return cached.token, nil
+		}
+		if request, ok := c.pending[serviceId]; ok {
+			c.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-request.done:
+			}
+			// A fetch that was given up on by its own caller is no answer
+			if request.err != nil && isContextError(request.err) && ctx.Err() == nil {
+				continue
+			}
+			return request.token.token, request.err
+		}
+
+		request := &tokenRequest{done: make(chan struct{})}
+		c.pending[serviceId] = request
+		c.mu.Unlock()
+
+		request.token, request.err = fetch(ctx)
+
+		c.mu.Lock()
+		delete(c.pending, serviceId)
+		if request.err == nil {
+			c.tokens[serviceId] = request.token
+		}
+		c.mu.Unlock()
+		close(request.done)
+		return request.token.token, request.err
+	}
+}
+
+// invalidate drops the cached token of a service if it is still the given
+// token, so that a token rejected by several requests is only replaced once.
+func (c *tokenCache) invalidate(serviceId string, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.tokens[serviceId]; ok && cached.token == token {
+		delete(c.tokens, serviceId)
+	}
+}
+
+// serviceAccessToken returns a service access token from the cache of the
+// provider, fetching a new one if needed.
+func (c *oscClient) serviceAccessToken(ctx context.Context, serviceId string) (string, error) {
+	return c.tokens.get(ctx, serviceId, func(ctx context.Context) (serviceToken, error) {
+		return getServiceAccessToken(ctx, c, serviceId)
+	})
+}
+
+// withServiceAccessToken calls fn with a service access token. If the token
+// is rejected, it is replaced and fn is called once more.
+func (c *oscClient) withServiceAccessToken(ctx context.Context, serviceId string, fn func(token string) error) error {
+	token, err := c.serviceAccessToken(ctx, serviceId)
+	if err != nil {
+		return err
+	}
+	err = fn(token)
+	if !errors.As(err, &osaasclient.UnauthorizedError{}) {
+		return err
+	}
+
+	c.tokens.invalidate(serviceId, token)
+	token, err = c.serviceAccessToken(ctx, serviceId)
+	if err != nil {
+		return err
+	}
+	return fn(token)
+}
+
+// tokenExpiry returns when a service access token expires. The token service
+// reports it in seconds or milliseconds since the epoch; failing that the
+// exp claim of the token is used, and failing that a default lifetime.
+func tokenExpiry(token osaasclient.ServiceAccessToken) time.Time {
+	switch {
+	case token.Expiry > 1e12:
+		return time.UnixMilli(token.Expiry)
+	case token.Expiry > 0:
+		return time.Unix(token.Expiry, 0)
+	}
+	if exp, ok := personalAccessTokenClaims(token.Token)["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Now().Add(defaultTokenLifetime)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
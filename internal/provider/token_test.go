@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+// countingFetch returns tokens "token-1", "token-2", ... valid for an hour,
+// after waiting for the given time.
+func countingFetch(fetches *atomic.Int32, wait time.Duration) func(context.Context) (serviceToken, error) {
+	return func(ctx context.Context) (serviceToken, error) {
+		n := fetches.Add(1)
+		select {
+		case <-ctx.Done():
+			return serviceToken{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		return serviceToken{token: fmt.Sprintf("token-%d", n), expiry: time.Now().Add(time.Hour)}, nil
+	}
+}
+
+func TestTokenCacheSingleFlight(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 50)
+	errs := make([]error, 50)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], errs[i] = cache.get(context.Background(), "svc", fetch)
+		}()
+	}
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches.Load())
+	}
+	for i := range tokens {
+		if errs[i] != nil || tokens[i] != "token-1" {
+			t.Errorf("caller %d got %q, %v", i, tokens[i], errs[i])
+		}
+	}
+}
+
+func TestTokenCachePerService(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 0)
+
+	first, _ := cache.get(context.Background(), "a", fetch)
+	second, _ := cache.get(context.Background(), "b", fetch)
+	again, _ := cache.get(context.Background(), "a", fetch)
+
+	if first == second {
+		t.Errorf("expected services to have their own token, both got %q", first)
+	}
+	if again != first || fetches.Load() != 2 {
+		t.Errorf("expected the token of a to be cached, got %q after %d fetches", again, fetches.Load())
+	}
+}
+
+func TestTokenCacheRefreshesExpiringToken(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 0)
+
+	cache.tokens["svc"] = serviceToken{token: "expiring", expiry: time.Now().Add(tokenRefreshMargin / 2)}
+	token, err := cache.get(context.Background(), "svc", fetch)
+	if err != nil || token != "token-1" {
+		t.Errorf("expected a token about to expire to be replaced, got %q, %v", token, err)
+	}
+
+	cache.tokens["svc"] = serviceToken{token: "valid", expiry: time.Now().Add(2 * tokenRefreshMargin)}
+	token, err = cache.get(context.Background(), "svc", fetch)
+	if err != nil || token != "valid" {
+		t.Errorf("expected a valid token to be kept, got %q, %v", token, err)
+	}
+}
+
+func TestTokenCacheDoesNotKeepErrors(t *testing.T) {
+	cache := newTokenCache()
+	failing := func(context.Context) (serviceToken, error) {
+		return serviceToken{}, errors.New("token service unavailable")
+	}
+	if _, err := cache.get(context.Background(), "svc", failing); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var fetches atomic.Int32
+	token, err := cache.get(context.Background(), "svc", countingFetch(&fetches, 0))
+	if err != nil || token != "token-1" {
+		t.Errorf("expected a new fetch after a failed one, got %q, %v", token, err)
+	}
+}
+
+func TestTokenCacheInvalidate(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 0)
+
+	token, _ := cache.get(context.Background(), "svc", fetch)
+	cache.invalidate("svc", "some other token")
+	if again, _ := cache.get(context.Background(), "svc", fetch); again != token {
+		t.Errorf("expected invalidating another token to keep %q, got %q", token, again)
+	}
+
+	cache.invalidate("svc", token)
+	if again, _ := cache.get(context.Background(), "svc", fetch); again != "token-2" {
+		t.Errorf("expected invalidating the token to fetch a new one, got %q", again)
+	}
+}
+
+func TestTokenCacheFirstCallerCancelled(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := cache.get(ctx, "svc", fetch)
+		firstDone <- err
+	}()
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	secondDone := make(chan string)
+	go func() {
+		token, err := cache.get(context.Background(), "svc", fetch)
+		if err != nil {
+			t.Errorf("expected the waiting caller to get a token, got %v", err)
+		}
+		secondDone <- token
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled caller to get its own error, got %v", err)
+	}
+	if token := <-secondDone; token != "token-2" {
+		t.Errorf("expected the waiting caller to fetch the token itself, got %q", token)
+	}
+}
+
+func TestTokenCacheWaitingCallerCancelled(t *testing.T) {
+	cache := newTokenCache()
+	var fetches atomic.Int32
+	fetch := countingFetch(&fetches, 200*time.Millisecond)
+
+	go func() { _, _ = cache.get(context.Background(), "svc", fetch) }()
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.get(ctx, "svc", fetch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the waiting caller to give up with its context, got %v", err)
+	}
+}
+
+func TestWithServiceAccessTokenRefreshesRejectedToken(t *testing.T) {
+	var issued atomic.Int32
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/mysubscriptions":
+			_, _ = w.Write([]byte(`[{"serviceId":"svc"}]`))
+		case "/servicetoken":
+			_, _ = fmt.Fprintf(w, `{"serviceId":"svc","token":"sat-%d"}`, issued.Add(1))
+		}
+	}))
+	defer server.Close()
+
+	osaasContext, err := osaasclient.NewContext(&osaasclient.ContextConfig{PersonalAccessToken: "pat", Environment: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newOscClient(osaasContext, server.URL, server.URL, server.URL, noRetry)
+
+	var used []string
+	err = client.withServiceAccessToken(context.Background(), "svc", func(token string) error {
+		calls.Add(1)
+		used = append(used, token)
+		if token == "sat-1" {
+			return osaasclient.UnauthorizedError{}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected the call with a new token to succeed, got %v", err)
+	}
+	if len(used) != 2 || used[1] != "sat-2" {
+		t.Errorf("expected the rejected token to be replaced, used %q", used)
+	}
+
+	// The new token is cached
+	_ = client.withServiceAccessToken(context.Background(), "svc", func(token string) error {
+		used = append(used, token)
+		return nil
+	})
+	if issued.Load() != 2 || used[2] != "sat-2" {
+		t.Errorf("expected the new token to be reused, used %q after %d tokens", used, issued.Load())
+	}
+
+	// A token that is still rejected is not replaced over and over
+	calls.Store(0)
+	err = client.withServiceAccessToken(context.Background(), "svc", func(token string) error {
+		calls.Add(1)
+		return osaasclient.UnauthorizedError{}
+	})
+	if !errors.As(err, &osaasclient.UnauthorizedError{}) || calls.Load() != 2 {
+		t.Errorf("expected one retry and then the error, got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		token osaasclient.ServiceAccessToken
+		want  time.Time
+	}{
+		{"seconds", osaasclient.ServiceAccessToken{Expiry: expiry.Unix()}, expiry},
+		{"milliseconds", osaasclient.ServiceAccessToken{Expiry: expiry.UnixMilli()}, expiry},
+		{"exp claim", osaasclient.ServiceAccessToken{Token: testJWT(t, expiry.Unix())}, expiry},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := tokenExpiry(test.token); !got.Equal(test.want) {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	got := tokenExpiry(osaasclient.ServiceAccessToken{Token: "opaque"})
+	if got.Before(time.Now().Add(defaultTokenLifetime-time.Minute)) || got.After(time.Now().Add(defaultTokenLifetime)) {
+		t.Errorf("expected the default lifetime for an opaque token, got %s", got)
+	}
+}
+
+// testJWT returns an unsigned JWT with the given exp claim.
+func testJWT(t *testing.T, exp int64) string {
+	t.Helper()
+	return testClaimsJWT(fmt.Sprintf(`{"exp":%d}`, exp))
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// instanceStateUpgraders returns the state upgraders of the instance
+// resources, whose schema is at version 1.
+func instanceStateUpgraders() map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: upgradeInstanceStateFromV0},
+	}
+}
+
+// upgradeInstanceStateFromV0 upgrades the state of an instance resource saved
+// before its parameters were typed after the catalog. Version 0 saved every
+// parameter the catalog does not type as a boolean as a string, so those are
+// converted to the type the attribute has now. Attributes added since are left null.
+func upgradeInstanceStateFromV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	if req.RawState == nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", "There is no saved state to upgrade.")
+		return
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(req.RawState.JSON, &values); err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", fmt.Sprintf("The saved state could not be parsed: %s", err))
+		return
+	}
+
+	attributes := resp.State.Schema.GetAttributes()
+	for name, value := range values {
+		attribute, ok := attributes[name]
+		if !ok {
+			continue
+		}
+		upgraded, err := upgradeValueFromV0(value, attribute.GetType())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(name), "Unable to Upgrade Resource State",
+				fmt.Sprintf("The saved value of %s %s. Correct it in the state, or remove the instance from the state with terraform state rm and import it again.", name, err))
+			continue
+		}
+		values[name] = upgraded
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	upgradedJSON, err := json.Marshal(values)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", err.Error())
+		return
+	}
+
+	rawState := tfprotov6.RawState{JSON: upgradedJSON}
+	state, err := rawState.UnmarshalWithOpts(resp.State.Schema.Type().TerraformType(ctx), tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", err.Error())
+		return
+	}
+	resp.State.Raw = state
+}
+
+// upgradeValueFromV0 converts the JSON value of an attribute saved as a string
+// to the given type. Values that were not saved as a string are kept as is, an
+// empty string becomes null for any other type.
+func upgradeValueFromV0(value json.RawMessage, target attr.Type) (json.RawMessage, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return value, nil
+	}
+	if target.Equal(types.StringType) {
+		return value, nil
+	}
+	if strings.TrimSpace(s) == "" {
+		return json.RawMessage("null"), nil
+	}
+
+	var upgraded interface{}
+	switch {
+	case target.Equal(types.BoolType):
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("is the string %q, which is not a boolean", s)
+		}
+		upgraded = b
+	case target.Equal(types.Int64Type):
+		i, ok := int64FromParameter(s)
+		if !ok {
+			return nil, fmt.Errorf("is the string %q, which is not an integer", s)
+		}
+		upgraded = i
+	case target.Equal(types.NumberType):
+		f, ok := numberFromParameter(s)
+		if !ok {
+			return nil, fmt.Errorf("is the string %q, which is not a number", s)
+		}
+		upgraded = json.Number(f.Text('g', -1))
+	case target.Equal(types.ListType{ElemType: types.StringType}):
+		list, _ := listFromParameter(s)
+		upgraded = listParameter(list)
+	default:
+		return nil, fmt.Errorf("is the string %q, which cannot be converted to %s", s, target)
+	}
+	return json.Marshal(upgraded)
+}
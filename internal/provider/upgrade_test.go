@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// upgradeChannelEngineState upgrades a version 0 state of the channel engine
+// resource the way the framework does.
+func upgradeChannelEngineState(t *testing.T, state string) *resource.UpgradeStateResponse {
+	t.Helper()
+	ctx := context.Background()
+	r := Newchannelengine().(resource.ResourceWithUpgradeState)
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	if schemaResp.Schema.Version != 1 {
+		t.Fatalf("expected schema version 1, got %d", schemaResp.Schema.Version)
+	}
+
+	upgrader, ok := r.UpgradeState(ctx)[0]
+	if !ok {
+		t.Fatal("expected a state upgrader from version 0")
+	}
+	req := resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(state)}}
+	resp := &resource.UpgradeStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	upgrader.StateUpgrader(ctx, req, resp)
+	return resp
+}
+
+func TestUpgradeInstanceStateFromV0(t *testing.T) {
+	tests := []struct {
+		name      string
+		attribute string
+		state     string
+		want      attr.Value
+	}{
+		{"comma separated list", "optslang_list", `{"name":"example","optslang_list":"en, sv"}`, types.ListValueMust(types.StringType, []attr.Value{types.StringValue("en"), types.StringValue("sv")})},
+		{"single element list", "optslang_list_subs", `{"name":"example","optslang_list_subs":"en"}`, types.ListValueMust(types.StringType, []attr.Value{types.StringValue("en")})},
+		{"empty list", "optslang_list", `{"name":"example","optslang_list":""}`, types.ListNull(types.StringType)},
+		{"null list", "optslang_list", `{"name":"example","optslang_list":null}`, types.ListNull(types.StringType)},
+		{"integer", "optsprerollduration", `{"name":"example","optsprerollduration":"30"}`, types.Int64Value(30)},
+		{"empty integer", "optsprerollduration", `{"name":"example","optsprerollduration":""}`, types.Int64Null()},
+		{"string", "type", `{"name":"example","type":"Loop"}`, types.StringValue("Loop")},
+		{"added attribute", "wait_for_ready", `{"name":"example"}`, types.BoolNull()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := upgradeChannelEngineState(t, test.state)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+			var got attr.Value
+			if diags := resp.State.GetAttribute(context.Background(), path.Root(test.attribute), &got); diags.HasError() {
+				t.Fatal(diags)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeInstanceStateFromV0NotConvertible(t *testing.T) {
+	resp := upgradeChannelEngineState(t, `{"name":"example","optsprerollduration":"thirty"}`)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error for an integer attribute saved as a non-numeric string")
+	}
+	if summary := resp.Diagnostics.Errors()[0].Summary(); summary != "Unable to Upgrade Resource State" {
+		t.Errorf("unexpected error %q", summary)
+	}
+}
+
+func TestUpgradeBoolFromV0(t *testing.T) {
+	got, err := upgradeValueFromV0([]byte(`"true"`), types.BoolType)
+	if err != nil || string(got) != "true" {
+		t.Errorf("got %s, %v, want true", got, err)
+	}
+	got, err = upgradeValueFromV0([]byte(`false`), types.BoolType)
+	if err != nil || string(got) != "false" {
+		t.Errorf("expected a saved boolean to be kept, got %s, %v", got, err)
+	}
+	if _, err := upgradeValueFromV0([]byte(`"yes please"`), types.BoolType); err == nil {
+		t.Error("expected an error for a string that is not a boolean")
+	}
+}
+
+func TestUpgradeNumberFromV0(t *testing.T) {
+	got, err := upgradeValueFromV0([]byte(`"0.5"`), types.NumberType)
+	if err != nil || string(got) != "0.5" {
+		t.Errorf("got %s, %v, want 0.5", got, err)
+	}
+	if _, err := upgradeValueFromV0([]byte(`"half"`), types.NumberType); err == nil {
+		t.Error("expected an error for a non-numeric string")
+	}
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCreateTimeout = 20 * time.Minute
+	defaultReadTimeout   = 5 * time.Minute
+	defaultDeleteTimeout = 10 * time.Minute
+	readyPollInterval    = 5 * time.Second
+)
+
+// terminalHealthStatuses are the health statuses an instance does not leave
+// on its own, so waiting for it to become ready is pointless.
+var terminalHealthStatuses = []string{"failed", "stopped"}
+
+type instanceHealth struct {
+	HealthStatus string `json:"healthStatus"`
+}
+
+// getInstanceHealth returns the health status OSC reports for an instance,
+// e.g. "starting" or "running".
+func getInstanceHealth(ctx context.Context, osaasContext *oscClient, serviceId string, name string) (string, error) {
+	service, err := getService(ctx, osaasContext, serviceId)
+	if err != nil {
+		return "", err
+	}
+
+	instanceURL, err := url.Parse(service.ApiUrl)
+	if err != nil {
+		return "", err
+	}
+
+	healthURL := fmt.Sprintf("%s://%s/health/%s", instanceURL.Scheme, instanceURL.Host, name)
+
+	var health instanceHealth
+	err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+		return createFetch(ctx, noRetry, healthURL, "GET", nil, &health, auth{"x-jwt", fmt.Sprintf("Bearer %s", token)})
+	})
+	if err != nil {
+		return "", err
+	}
+	return health.HealthStatus, nil
+}
+
+// checkInstanceUrl returns nil once the instance answers a GET on the given
+// path with a 2xx status.
+func checkInstanceUrl(ctx context.Context, instanceUrl string, path string, token string) error {
+	checkURL := strings.TrimSuffix(instanceUrl, "/") + "/" + strings.TrimPrefix(path, "/")
+	return createFetch(ctx, noRetry, checkURL, "GET", nil, nil, auth{"Authorization", fmt.Sprintf("Bearer %s", token)})
+}
+
+// waitForInstanceReady polls an instance until OSC reports it as running and,
+// if a health check path is given, the instance answers on it. It gives up
+// when the context is done, or right away when the instance has failed or
+// stopped.
+func waitForInstanceReady(ctx context.Context, osaasContext *oscClient, serviceId string, name string, instanceUrl string, healthCheckPath string) error {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	lastStatus := "unknown"
+	for {
+		status, err := getInstanceHealth(ctx, osaasContext, serviceId, name)
+		if err == nil {
+			lastStatus = status
+		} else {
+			lastStatus = err.Error()
+		}
+
+		if slices.Contains(terminalHealthStatuses, strings.ToLower(status)) {
+			return fmt.Errorf("instance %q of service %s will not become ready, its status is %s", name, serviceId, status)
+		}
+
+		if status == "running" {
+			if healthCheckPath == "" {
+				return nil
+			}
+			err = osaasContext.withServiceAccessToken(ctx, serviceId, func(token string) error {
+				return checkInstanceUrl(ctx, instanceUrl, healthCheckPath, token)
+			})
+			if err == nil {
+				return nil
+			}
+			lastStatus = fmt.Sprintf("health check on %s failed: %s", healthCheckPath, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("instance %q of service %s did not become ready, last status: %s", name, serviceId, lastStatus)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForInstanceRemoved polls an instance until OSC no longer knows about it,
+// so that an instance with the same name can be created right away. It gives
+// up when the context is done.
+func waitForInstanceRemoved(ctx context.Context, osaasContext *oscClient, serviceId string, name string) error {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		instance, err := getInstance(ctx, osaasContext, serviceId, name)
+		if err == nil && instance == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("instance %q of service %s was not removed: %w", name, serviceId, err)
+			}
+			return fmt.Errorf("instance %q of service %s was not removed: %w", name, serviceId, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
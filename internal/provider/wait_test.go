@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	osaasclient "github.com/EyevinnOSC/client-go"
+)
+
+// newHealthServer stands in for the OSC API of a service "svc" whose instance
+// "example" always reports the given health status, counting the polls.
+func newHealthServer(t *testing.T, status string) (*oscClient, *atomic.Int32) {
+	t.Helper()
+	var polls atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/mysubscriptions":
+			_, _ = fmt.Fprintf(w, `[{"serviceId":"svc","apiUrl":"%s/svc"}]`, server.URL)
+		case "/servicetoken":
+			_, _ = w.Write([]byte(`{"serviceId":"svc","token":"sat"}`))
+		case "/health/example":
+			polls.Add(1)
+			_, _ = fmt.Fprintf(w, `{"healthStatus":%q}`, status)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	osaasContext, err := osaasclient.NewContext(&osaasclient.ContextConfig{PersonalAccessToken: "pat", Environment: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newOscClient(osaasContext, server.URL, server.URL, server.URL, noRetry), &polls
+}
+
+func TestWaitForInstanceReady(t *testing.T) {
+	client, _ := newHealthServer(t, "running")
+	if err := waitForInstanceReady(context.Background(), client, "svc", "example", "", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForInstanceReadyStopsOnTerminalStatus(t *testing.T) {
+	for _, status := range terminalHealthStatuses {
+		t.Run(status, func(t *testing.T) {
+			client, polls := newHealthServer(t, status)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			err := waitForInstanceReady(ctx, client, "svc", "example", "", "")
+			if err == nil {
+				t.Fatal("expected an error for an instance that will not become ready")
+			}
+			if !strings.Contains(err.Error(), status) {
+				t.Errorf("expected the error to name the status, got %q", err)
+			}
+			if polls.Load() != 1 {
+				t.Errorf("expected to stop after the first poll, got %d", polls.Load())
+			}
+		})
+	}
+}
+
+func TestWaitForInstanceReadyGivesUpWhenContextIsDone(t *testing.T) {
+	client, _ := newHealthServer(t, "starting")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := waitForInstanceReady(ctx, client, "svc", "example", "", "")
+	if err == nil || !strings.Contains(err.Error(), "last status: starting") {
+		t.Errorf("expected the last status in the error, got %v", err)
+	}
+}
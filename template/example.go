@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exampleValue returns the value of a mandatory parameter in the usage example
+// of a resource. Sensitive values are taken from a variable of the same name.
+func exampleValue(option ServiceInstanceOption, name string, sensitive bool) string {
+	if sensitive {
+		return fmt.Sprintf("var.%s", name)
+	}
+	switch option.Type {
+	case "boolean":
+		return "true"
+	case "integer", "number":
+		if option.Min != nil {
+			return fmt.Sprint(*option.Min)
+		}
+		return "1"
+	case "list":
+		return fmt.Sprintf("[%q]", "<"+name+">")
+	case "enum":
+		if len(option.Enum) > 0 {
+			return fmt.Sprintf("%q", option.Enum[0])
+		}
+	}
+	if option.Name == "name" {
+		return `"example"`
+	}
+	return fmt.Sprintf("%q", "<"+name+">")
+}
+
+// alignedAttributes renders HCL attributes with their equals signs aligned,
+// as `terraform fmt` does.
+func alignedAttributes(builder *strings.Builder, names []string, values []string) {
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	for i, name := range names {
+		builder.WriteString(fmt.Sprintf("  %-*s = %s\n", width, name, values[i]))
+	}
+}
+
+// variableType returns the Terraform type of a variable holding a parameter.
+func variableType(t string) string {
+	switch t {
+	case "types.Bool":
+		return "bool"
+	case "types.Int64", "types.Number":
+		return "number"
+	case "types.List":
+		return "list(string)"
+	default:
+		return "string"
+	}
+}
+
+// renderExample renders the usage example of a resource, setting its
+// mandatory parameters.
+func renderExample(resource Resource) []byte {
+	var builder strings.Builder
+	var names, values []string
+	for _, parameter := range resource.InputParameters {
+		if parameter.Example == "" {
+			continue
+		}
+		if parameter.Sensitive {
+			builder.WriteString(fmt.Sprintf("variable %q {\n", parameter.Name))
+			alignedAttributes(&builder, []string{"type", "sensitive"}, []string{variableType(parameter.Type), "true"})
+			builder.WriteString("}\n\n")
+		}
+		names = append(names, parameter.Name)
+		values = append(values, parameter.Example)
+	}
+	builder.WriteString(fmt.Sprintf("resource %q \"example\" {\n", resource.ResourceName))
+	alignedAttributes(&builder, names, values)
+	builder.WriteString("}\n")
+	return []byte(builder.String())
+}
+
+// renderImport renders the import example of a resource.
+func renderImport(resource Resource) []byte {
+	return []byte(fmt.Sprintf(`# An instance is imported by its name, optionally prefixed with the service id,
+# e.g. %s/example
+#
+# Parameters the OSC API does not return, such as sensitive ones, are left out
+# of the imported state. Setting them in the configuration afterwards records
+# them without replacing the instance, changing any other parameter replaces it.
+terraform import %s.example example
+`, resource.ServiceID, resource.ResourceName))
+}
+
+func examplePath(resourceName string) string {
+	return fmt.Sprintf("../examples/resources/%s", resourceName)
+}
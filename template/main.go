@@ -3,12 +3,17 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"html/template"
+	"text/template"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -69,16 +74,40 @@ type OscContext struct {
 type InputParameter struct {
 	Name            string `json:"name"`
 	NameInteral     string `json:"Name"`
+	Key             string `json:"key"`
 	Type            string `json:"type"`
 	Flag            string `json:"flag"`
 	SchemaAttribute string `json:"schemaAttribute"`
+	PlanModifierType string `json:"planModifierType"`
 	Value           string `json:"value"`
 	Description		string `json:"description"`
+	Sensitive       bool   `json:"sensitive"`
+	Validators      []string `json:"validators"`
+	Default         string `json:"default"`
+	Updatable       bool   `json:"updatable"`
+	Example         string `json:"example"`
+}
+
+// ServiceInstanceOption extends the client-go option with the catalog fields
+// the generator needs but client-go does not decode.
+type ServiceInstanceOption struct {
+	osaasclient.ServiceInstanceOption
+	Sensitive *bool    `json:"sensitive"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	Default   interface{} `json:"default"`
+	Pattern   string   `json:"pattern"`
+}
+
+type Service struct {
+	osaasclient.Service
+	ServiceInstanceOptions []ServiceInstanceOption `json:"serviceInstanceOptions"`
 }
 
 type InstanceParameter struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name    string `json:"name"`
+	Field   string `json:"field"`
+	AsArray bool   `json:"asArray"`
 }
 
 type Resource struct {
@@ -88,6 +117,8 @@ type Resource struct {
 	ServiceID         string               `json:"serviceId"`
 	InstanceParameters []InstanceParameter `json:"instanceParameters"`
 	Description			string				`json:"description"`
+	Imports				[]string			`json:"imports"`
+	DeprecationMessage	string				`json:"deprecationMessage"`
 }
 
 var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
@@ -101,9 +132,11 @@ func ToSnakeCase(str string) string {
 func typeMap(t string) string {
 	switch (t) {
 		case "string":	return "types.String"
-		case "boolean": return "bool"
+		case "boolean": return "types.Bool"
+		case "integer":	return "types.Int64"
+		case "number":	return "types.Number"
 		case "enum":	return "types.String"
-		case "list":	return "string"
+		case "list":	return "types.List"
 		default:		return "types.String"
 	}
 }
@@ -112,12 +145,145 @@ func typeMap(t string) string {
 func attributeMap(t string) string {
 	switch (t) {
 		case "boolean":			return "BoolAttribute"
+		case "integer":			return "Int64Attribute"
+		case "number":			return "NumberAttribute"
 		case "enum":			return "StringAttribute"
-		case "string", "list":	return "StringAttribute"
+		case "list":			return "ListAttribute"
+		case "string":			return "StringAttribute"
 		default:				return "StringAttribute"
 	}
 }
 
+// planModifierMap returns the plan modifier type matching the schema
+// attribute, e.g. "String" for a StringAttribute.
+func planModifierMap(t string) string {
+	switch (t) {
+		case "boolean":			return "Bool"
+		case "integer":			return "Int64"
+		case "number":			return "Number"
+		case "list":			return "List"
+		default:				return "String"
+	}
+}
+
+// validatorMap returns the validators for a parameter from the hints in the
+// catalog, e.g. the range of an integer.
+func validatorMap(option ServiceInstanceOption) []string {
+	var validators []string
+	if option.Type == "integer" {
+		switch {
+		case option.Min != nil && option.Max != nil:
+			validators = append(validators, (fmt.Sprintf("int64validator.Between(%d, %d)", int64(*option.Min), int64(*option.Max))))
+		case option.Min != nil:
+			validators = append(validators, (fmt.Sprintf("int64validator.AtLeast(%d)", int64(*option.Min))))
+		case option.Max != nil:
+			validators = append(validators, (fmt.Sprintf("int64validator.AtMost(%d)", int64(*option.Max))))
+		}
+	}
+	if (option.Type == "string" || option.Type == "enum") && option.Pattern != "" {
+		validators = append(validators, (fmt.Sprintf("stringvalidator.RegexMatches(regexp.MustCompile(%q), %q)", option.Pattern, "must match "+option.Pattern)))
+	}
+	if option.Type == "enum" && len(option.Enum) > 0 {
+		values := make([]string, len(option.Enum))
+		for i, value := range option.Enum {
+			values[i] = fmt.Sprintf("%q", value)
+		}
+		validators = append(validators, (fmt.Sprintf("stringvalidator.OneOf(%s)", strings.Join(values, ", "))))
+	}
+	return validators
+}
+
+// descriptionMap returns the attribute description, listing the allowed
+// values of an enum.
+func descriptionMap(option ServiceInstanceOption) string {
+	if option.Type != "enum" || len(option.Enum) == 0 {
+		return option.Description
+	}
+	description := strings.TrimSpace(option.Description)
+	if description != "" && !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s Allowed values: %s.", description, strings.Join(option.Enum, ", ")))
+}
+
+// withDefault appends the default value of a parameter to its description.
+func withDefault(description string, value string) string {
+	description = strings.TrimSpace(description)
+	if description != "" && !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s Defaults to `%s`.", description, value))
+}
+
+// defaultMap returns the schema default of an optional parameter with a
+// default value in the catalog, the package providing it and the default as
+// shown in the description. It returns an empty default when there is none
+// or it does not fit the parameter type.
+func defaultMap(option ServiceInstanceOption) (string, string, string) {
+	if option.Mandatory || option.Default == nil {
+		return "", "", ""
+	}
+	switch option.Type {
+	case "boolean":
+		value, ok := option.Default.(bool)
+		if s, isString := option.Default.(string); isString {
+			parsed, err := strconv.ParseBool(s)
+			value, ok = parsed, err == nil
+		}
+		if !ok {
+			break
+		}
+		return (fmt.Sprintf("booldefault.StaticBool(%t)", value)), "booldefault", strconv.FormatBool(value)
+	case "integer", "number":
+		value, ok := option.Default.(float64)
+		if s, isString := option.Default.(string); isString {
+			parsed, err := strconv.ParseFloat(s, 64)
+			value, ok = parsed, err == nil
+		}
+		if !ok {
+			break
+		}
+		text := strconv.FormatFloat(value, 'g', -1, 64)
+		if option.Type == "integer" {
+			if value != float64(int64(value)) {
+				break
+			}
+			return (fmt.Sprintf("int64default.StaticInt64(%d)", int64(value))), "int64default", text
+		}
+		return (fmt.Sprintf("numberdefault.StaticBigFloat(big.NewFloat(%s))", text)), "numberdefault", text
+	case "list":
+		var values []string
+		switch v := option.Default.(type) {
+		case string:
+			for _, value := range strings.Split(v, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					values = append(values, value)
+				}
+			}
+		case []interface{}:
+			for _, value := range v {
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+		elements := make([]string, len(values))
+		for i, value := range values {
+			elements[i] = fmt.Sprintf("types.StringValue(%q)", value)
+		}
+		return (fmt.Sprintf("listdefault.StaticValue(types.ListValueMust(types.StringType, []attr.Value{%s}))", strings.Join(elements, ", "))), "listdefault", strings.Join(values, ",")
+	default:
+		value := fmt.Sprint(option.Default)
+		if s, isString := option.Default.(string); isString {
+			value = s
+		}
+		if option.Type == "enum" && len(option.Enum) > 0 && !slices.Contains(option.Enum, value) {
+			break
+		}
+		return (fmt.Sprintf("stringdefault.StaticString(%q)", value)), "stringdefault", value
+	}
+	fmt.Printf("Ignoring default %v of %s parameter %s\n", option.Default, option.Type, option.Name)
+	return "", "", ""
+}
+
 func flagMap(f bool) string {
 	if f == true {
 		return "Required"
@@ -155,6 +321,99 @@ func sanitizeToVariableName(input string) string {
 type Config struct {
 	ServiceIgnore []string `json:"serviceIgnore"`
 	ServiceIgnoreMap map[string]struct{}
+	SensitivePatterns []string `json:"sensitivePatterns"`
+	SensitiveInclude []string `json:"sensitiveInclude"`
+	SensitiveExclude []string `json:"sensitiveExclude"`
+	ListParametersAsArray []string `json:"listParametersAsArray"`
+	Overrides map[string]map[string]ParameterOverride `json:"overrides"`
+}
+
+// ParameterOverride corrects or tightens a catalog parameter of a service.
+// Fields left unset keep what the catalog says.
+type ParameterOverride struct {
+	Attribute   string      `json:"attribute"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Sensitive   *bool       `json:"sensitive"`
+	Enums       []string    `json:"enums"`
+	Min         *float64    `json:"min"`
+	Max         *float64    `json:"max"`
+	Pattern     string      `json:"pattern"`
+	Default     interface{} `json:"default"`
+	Updatable   bool        `json:"updatable"`
+}
+
+// override applies the override configured for a parameter of a service, if
+// any, and returns it.
+func (c *Config) override(serviceId string, option ServiceInstanceOption) (ServiceInstanceOption, ParameterOverride) {
+	override, ok := c.Overrides[serviceId][option.Name]
+	if !ok {
+		return option, override
+	}
+	if override.Type != "" {
+		option.Type = override.Type
+	}
+	if override.Description != "" {
+		option.Description = override.Description
+	}
+	if override.Sensitive != nil {
+		option.Sensitive = override.Sensitive
+	}
+	if len(override.Enums) > 0 {
+		option.Enum = override.Enums
+		if option.Type == "string" {
+			option.Type = "enum"
+		}
+	}
+	if override.Min != nil {
+		option.Min = override.Min
+	}
+	if override.Max != nil {
+		option.Max = override.Max
+	}
+	if override.Pattern != "" {
+		option.Pattern = override.Pattern
+	}
+	if override.Default != nil {
+		option.Default = override.Default
+	}
+	return option, override
+}
+
+// listAsArray reports whether a list parameter is sent to the service as a
+// JSON array rather than as a comma separated string.
+func (c *Config) listAsArray(serviceId string, option ServiceInstanceOption) bool {
+	for _, name := range c.ListParametersAsArray {
+		if name == fmt.Sprintf("%s/%s", serviceId, option.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitive reports whether a parameter holds a secret. The catalog decides
+// when it says so, otherwise the name is matched against the configured
+// include and exclude lists and finally the sensitive name patterns.
+func (c *Config) isSensitive(option ServiceInstanceOption) bool {
+	if option.Sensitive != nil {
+		return *option.Sensitive
+	}
+	for _, name := range c.SensitiveInclude {
+		if name == option.Name {
+			return true
+		}
+	}
+	for _, name := range c.SensitiveExclude {
+		if name == option.Name {
+			return false
+		}
+	}
+	for _, pattern := range c.SensitivePatterns {
+		if strings.Contains(strings.ToLower(option.Name), strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
 }
 
 func readSeviceIgnoreList() (*Config, error) {
@@ -180,28 +439,269 @@ func readSeviceIgnoreList() (*Config, error) {
 	return &config, nil
 }
 
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	refresh   = flag.Bool("refresh", false, "fetch the catalog from the OSC catalog API and update the snapshot before generating")
+	check     = flag.Bool("check", false, "compare the generated files with the snapshot output instead of writing them, exit with status 1 if any differ")
+	prune     = flag.Bool("prune", false, "remove the resources of services that were deprecated in an earlier run")
+	changelog = flag.String("changelog", "", "write the changelog section listing added, changed, deprecated and removed services to this file")
+)
+
+// catalogSnapshot is the checked-in copy of the catalog the resources are
+// generated from.
+const catalogSnapshot = "catalog.json"
+
+// refreshCatalog fetches the catalog from the OSC catalog API and writes it to
+// the snapshot, with services sorted by id so that changes diff cleanly.
+func refreshCatalog(ctx *OscContext) error {
+	serviceURL := fmt.Sprintf("https://catalog.svc.%s.osaas.io/service", ctx.Environment)
+	var services []map[string]interface{}
+	err := createFetch(serviceURL, "GET", nil, &services, Auth{"Authorization", fmt.Sprintf("Bearer %s", ctx.ApiKey)})
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("catalog API returned no services")
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return fmt.Sprint(services[i]["serviceId"]) < fmt.Sprint(services[j]["serviceId"])
+	})
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(services); err != nil {
+		return err
+	}
+	return os.WriteFile(catalogSnapshot, data.Bytes(), 0644)
+}
+
+// readCatalog reads the services from the catalog snapshot.
+func readCatalog() ([]Service, error) {
+	data, err := os.ReadFile(catalogSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	var services []Service
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// buildResource builds the template context of the resource for a service.
+func buildResource(config *Config, element Service) Resource {
+	var caser = cases.Title(language.English)
+	var inputParameters []InputParameter
+	var instanceParameters []InstanceParameter
+	imports := map[string]struct{}{}
+	for _, option := range element.ServiceInstanceOptions {
+		inputParameter, override := config.override(element.ServiceId, option)
+		var sanitizedName = sanitizeToVariableName(inputParameter.Name)
+		var name = ToSnakeCase(sanitizedName)
+		var nameInternal = caser.String(sanitizedName)
+		if override.Attribute != "" {
+			name = override.Attribute
+			nameInternal = caser.String(sanitizeToVariableName(strings.ReplaceAll(override.Attribute, "_", "")))
+		}
+		planModifierType := planModifierMap(inputParameter.Type)
+		defaultValue, defaultPackage, defaultText := defaultMap(inputParameter)
+		description := descriptionMap(inputParameter)
+		if defaultValue != "" {
+			description = withDefault(description, defaultText)
+		}
+		var i = InputParameter{
+				Name:            name,
+				NameInteral:     nameInternal,
+				Key:             inputParameter.Name,
+				Type:            typeMap(inputParameter.Type),
+				Flag:            flagMap(inputParameter.Mandatory),
+				SchemaAttribute: attributeMap(inputParameter.Type),
+				PlanModifierType: planModifierType,
+				Value:           fmt.Sprintf("plan.%s", nameInternal),
+				Description:	 description,
+				Sensitive:       config.isSensitive(inputParameter),
+				Validators:      validatorMap(inputParameter),
+				Default:         defaultValue,
+				Updatable:       override.Updatable,
+		}
+		if i.Updatable && i.Key == "name" {
+			fmt.Printf("Ignoring updatable parameter %s of %s, the name identifies the instance\n", i.Key, element.ServiceId)
+			i.Updatable = false
+		}
+		if inputParameter.Mandatory {
+			i.Example = exampleValue(inputParameter, i.Name, i.Sensitive)
+		}
+		inputParameters = append(inputParameters, i)
+		if len(i.Validators) > 0 {
+			imports["github.com/hashicorp/terraform-plugin-framework/schema/validator"] = struct{}{}
+		}
+		// booldefault is always imported by the template
+		if defaultPackage != "" && defaultPackage != "booldefault" {
+			imports[fmt.Sprintf("github.com/hashicorp/terraform-plugin-framework/resource/schema/%s", defaultPackage)] = struct{}{}
+		}
+		switch inputParameter.Type {
+		case "integer":
+			if len(i.Validators) > 0 {
+				imports["github.com/hashicorp/terraform-plugin-framework-validators/int64validator"] = struct{}{}
+			}
+		case "number":
+			if i.Default != "" {
+				imports["math/big"] = struct{}{}
+			}
+		case "string", "enum":
+			if len(i.Validators) > 0 {
+				imports["github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"] = struct{}{}
+			}
+			if inputParameter.Pattern != "" {
+				imports["regexp"] = struct{}{}
+			}
+		}
+
+		var instanceParameter = InstanceParameter{
+			Name: inputParameter.Name,
+			Field: fmt.Sprintf("plan.%s", nameInternal),
+			AsArray: inputParameter.Type == "list" && config.listAsArray(element.ServiceId, inputParameter),
+		}
+		instanceParameters = append(instanceParameters, instanceParameter)
+	}
+	for _, name := range sortedKeys(config.Overrides[element.ServiceId]) {
+		if !slices.ContainsFunc(element.ServiceInstanceOptions, func(option ServiceInstanceOption) bool { return option.Name == name }) {
+			fmt.Printf("Ignoring override of unknown parameter %s of %s\n", name, element.ServiceId)
+		}
+	}
+	resourceName := fmt.Sprintf("osc_%s", strings.ReplaceAll(element.ServiceId, "-", "_"))
+	resource := Resource{
+		ObjectName: strings.ReplaceAll(element.ServiceId, "-", ""),
+		ResourceName: resourceName,
+		Description: element.Metadata.Description,
+		InputParameters: inputParameters,
+		ServiceID: element.ServiceId,
+		InstanceParameters: instanceParameters, 
+		Imports: sortedKeys(imports),
+	}
+	return resource
+}
+
+// parseResourceTemplate parses the template resources are rendered from.
+func parseResourceTemplate() (*template.Template, error) {
+	return template.New("resource.tpl").Funcs(template.FuncMap{"quote": strconv.Quote}).ParseFiles("template/resource.tpl")
+}
+
+// writeResource renders the resource, its usage example and import example
+// and writes them to internal/provider and examples/resources. In check mode
+// nothing is written and it reports whether the files on disk differ from the
+// rendered ones.
+func writeResource(tmpl *template.Template, resource Resource) (bool, error) {
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, resource); err != nil {
+		return false, err
+	}
+
+	outdated := false
+	files := map[string][]byte{
+		resourcePath(resource.ResourceName):                              output.Bytes(),
+		filepath.Join(examplePath(resource.ResourceName), "resource.tf"): renderExample(resource),
+		filepath.Join(examplePath(resource.ResourceName), "import.sh"):   renderImport(resource),
+	}
+	for _, path := range sortedKeys(files) {
+		isOutdated, err := writeGenerated(path, files[path])
+		if err != nil {
+			return false, err
+		}
+		outdated = outdated || isOutdated
+	}
+	return outdated, nil
+}
+
+// writeGenerated writes a generated file or, in check mode, reports whether
+// the file on disk differs from it.
+func writeGenerated(path string, data []byte) (bool, error) {
+	if *check {
+		existing, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(existing, data) {
+			fmt.Println("Out of date:", path)
+			return true, nil
+		}
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(path, data, 0644)
+}
+
+func resourcePath(resourceName string) string {
+	return fmt.Sprintf("../internal/provider/%s.go", resourceName)
+}
+
 func main() {
+	flag.Parse()
+
 	config, err := readSeviceIgnoreList()
 	if err != nil {
 		fmt.Println(err)
-		return
+		os.Exit(1)
 	}
 
-	ctx := &OscContext{
-		Environment: "prod",
-		PersonalAccessToken:  os.Getenv("OSC_ACCESS_TOKEN"),
-		ApiKey: os.Getenv("OSC_API_KEY"),
+	// The catalog entries before a refresh, to keep generating resources of
+	// services removed from the catalog as deprecated.
+	previousEntries, err := readCatalogEntries(catalogSnapshot)
+	if err != nil {
+		fmt.Println("Error reading catalog snapshot:", err)
+		os.Exit(1)
 	}
 
-	serviceURL := fmt.Sprintf("https://catalog.svc.%s.osaas.io/service", ctx.Environment)
-	var services []osaasclient.Service
-	err = createFetch(serviceURL, "GET", nil, &services, Auth{"Authorization", fmt.Sprintf("Bearer %s", ctx.ApiKey)})
+	if *refresh {
+		ctx := &OscContext{
+			Environment: "prod",
+			PersonalAccessToken:  os.Getenv("OSC_ACCESS_TOKEN"),
+			ApiKey: os.Getenv("OSC_API_KEY"),
+		}
+		if err := refreshCatalog(ctx); err != nil {
+			fmt.Println("Error refreshing catalog snapshot:", err)
+			os.Exit(1)
+		}
+	}
+
+	services, err := readCatalog()
 	if err != nil {
-		fmt.Println(err)
-		return
-	}	
-	var caser = cases.Title(language.English)
+		fmt.Println("Error reading catalog snapshot:", err)
+		os.Exit(1)
+	}
+
+	// Parse the template
+	tmpl, err := parseResourceTemplate()
+	if err != nil {
+		fmt.Println("Error parsing template:", err)
+		os.Exit(1)
+	}
+
+	manifest, err := readManifest()
+	if err != nil {
+		fmt.Println("Error reading service manifest:", err)
+		os.Exit(1)
+	}
+	entries, err := readCatalogEntries(catalogSnapshot)
+	if err != nil {
+		fmt.Println("Error reading catalog snapshot:", err)
+		os.Exit(1)
+	}
+	next := &Manifest{Services: map[string]ManifestEntry{}}
+	changes := &Changes{}
+
+	outdated := 0
 	counter := 1
+	published := map[string]struct{}{}
 	for _, element := range services {
 		if _, shouldSkip := config.ServiceIgnoreMap[element.ServiceId]; shouldSkip {
 			fmt.Println("Skipping:", element.ServiceId)
@@ -213,60 +713,98 @@ func main() {
 		}
 		fmt.Println(counter, element.ServiceId)
 		counter++
-		var inputParameters []InputParameter
-		var instanceParameters []InstanceParameter
-		for _, inputParameter := range element.ServiceInstanceOptions {
-			var sanitizedName = sanitizeToVariableName(inputParameter.Name)
-			var nameInternal = caser.String(sanitizedName)
-			var i = InputParameter{
-					Name:            ToSnakeCase(sanitizedName),
-					NameInteral:     nameInternal,
-					Type:            typeMap(inputParameter.Type),
-					Flag:            flagMap(inputParameter.Mandatory),
-					SchemaAttribute: attributeMap(inputParameter.Type),
-					Value:           fmt.Sprintf("plan.%s", nameInternal),
-					Description:	 inputParameter.Description,
-			}
-			inputParameters = append(inputParameters, i)
+		published[element.ServiceId] = struct{}{}
+
+		resource := buildResource(config, element)
+		if err := next.track(manifest, element.ServiceId, resource.ResourceName, entries[element.ServiceId], changes); err != nil {
+			fmt.Println("Error tracking service:", err)
+			os.Exit(1)
+		}
 
-			var suffix = ""
-			if inputParameter.Type == "string" {
-				suffix = ".ValueString()"
+		isOutdated, err := writeResource(tmpl, resource)
+		if err != nil {
+			fmt.Println("Error writing resource:", err)
+			os.Exit(1)
+		}
+		if isOutdated {
+			outdated++
+		}
+	}
+
+	// Services the manifest knows about that are no longer published keep
+	// their resource, marked as deprecated, until pruned.
+	for _, serviceId := range sortedKeys(manifest.Services) {
+		known := manifest.Services[serviceId]
+		if _, ok := published[serviceId]; ok {
+			continue
+		}
+		if _, shouldSkip := config.ServiceIgnoreMap[serviceId]; shouldSkip {
+			continue
+		}
+
+		if known.Deprecated != "" && *prune {
+			fmt.Println("Pruning:", serviceId)
+			changes.Removed = append(changes.Removed, known.Resource)
+			if *check {
+				if _, err := os.Stat(resourcePath(known.Resource)); err == nil {
+					fmt.Println("Out of date:", resourcePath(known.Resource))
+					outdated++
+				}
+				continue
 			}
-			var instanceParameter = InstanceParameter{
-				Name: inputParameter.Name,
-				Value: fmt.Sprintf("plan.%s%s", nameInternal, suffix),
+			if err := os.Remove(resourcePath(known.Resource)); err != nil && !os.IsNotExist(err) {
+				fmt.Println("Error removing resource:", err)
+				os.Exit(1)
 			}
-			instanceParameters = append(instanceParameters, instanceParameter)
-		}
-        resourceName := fmt.Sprintf("osc_%s", strings.ReplaceAll(element.ServiceId, "-", "_"))
-		resource := Resource{
-			ObjectName: strings.ReplaceAll(element.ServiceId, "-", ""),
-			ResourceName: resourceName,
-			Description: element.Metadata.Description,
-			InputParameters: inputParameters,
-			ServiceID: element.ServiceId,
-			InstanceParameters: instanceParameters, 
+			if err := os.RemoveAll(examplePath(known.Resource)); err != nil {
+				fmt.Println("Error removing resource example:", err)
+				os.Exit(1)
+			}
+			continue
 		}
 
-		// Create or open the output file
-		outputFile, err := os.Create(fmt.Sprintf("../internal/provider/%s.go", resourceName))
+		entry, err := next.deprecate(manifest, serviceId, entries, previousEntries, changes)
 		if err != nil {
-			fmt.Println("Error creating output file:", err)
-			return
+			fmt.Println("Error deprecating resource:", err)
+			os.Exit(1)
 		}
-		defer outputFile.Close()
+		var element Service
+		if err := json.Unmarshal(entry.Service, &element); err != nil {
+			fmt.Println("Error reading catalog entry of deprecated service:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Deprecated:", serviceId)
 
-		// Parse and execute the template
-		tmpl, err := template.ParseFiles("template/resource.tpl")
+		resource := buildResource(config, element)
+		resource.DeprecationMessage = entry.Deprecated
+		isOutdated, err := writeResource(tmpl, resource)
 		if err != nil {
-			fmt.Println("Error parsing template:", err)
-			return
+			fmt.Println("Error writing resource:", err)
+			os.Exit(1)
+		}
+		if isOutdated {
+			outdated++
 		}
+	}
 
-		if err := tmpl.Execute(outputFile, resource); err != nil {
-			fmt.Println("Error executing template:", err)
-			return
+	if *check {
+		if isOutdated, err := next.check(); err != nil || isOutdated {
+			fmt.Println("Out of date:", manifestFile)
+			outdated++
+		}
+	} else {
+		if err := next.write(); err != nil {
+			fmt.Println("Error writing service manifest:", err)
+			os.Exit(1)
+		}
+		if err := changes.write(*changelog); err != nil {
+			fmt.Println("Error writing changelog:", err)
+			os.Exit(1)
 		}
 	}
+
+	if outdated > 0 {
+		fmt.Printf("%d generated files differ from the catalog snapshot, run `go run .` to regenerate them\n", outdated)
+		os.Exit(1)
+	}
 }
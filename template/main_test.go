@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testService is a catalog entry whose descriptions hold the characters that
+// break Go string literals: double quotes, backticks, backslashes and newlines.
+const testService = `{
+	"serviceId": "test-quoting",
+	"status": "PUBLISHED",
+	"serviceMetadata": {"description": "Serves \"quoted\" and ` + "`backticked`" + ` text,\nwith a C:\\path on a second line"},
+	"serviceInstanceOptions": [
+		{"name": "name", "type": "string", "mandatory": true, "description": "Name of the \"instance\""},
+		{"name": "Title", "type": "string", "description": "The ` + "`title`" + ` shown, e.g. \"Hello\""},
+		{"name": "Mode", "type": "enum", "enums": ["\"a\"", "b` + "`" + `c"], "description": "A mode"},
+		{"name": "Workers", "type": "integer", "min": 1, "default": 2, "description": "Workers, \\d+"},
+		{"name": "Debug", "type": "boolean", "description": "Debug ` + "```" + `"},
+		{"name": "Origins", "type": "list", "description": "Allowed \"origins\""},
+		{"name": "ApiKey", "type": "string", "description": "The key"}
+	]
+}`
+
+func TestRenderResourceWithQuotesAndBackticks(t *testing.T) {
+	var service Service
+	if err := json.Unmarshal([]byte(testService), &service); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		SensitivePatterns: []string{"key"},
+		Overrides: map[string]map[string]ParameterOverride{
+			"test-quoting": {"Title": {Updatable: true, Description: "The \"title\" of `it`"}},
+		},
+	}
+
+	tmpl, err := parseResourceTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := buildResource(config, service)
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := format.Source(output.Bytes())
+	if err != nil {
+		t.Fatalf("rendered resource is not valid Go: %v\n%s", err, output.String())
+	}
+	for _, description := range []string{service.Metadata.Description, "The \"title\" of `it`", "Name of the \"instance\""} {
+		if !bytes.Contains(formatted, []byte(strconv.Quote(description))) {
+			t.Errorf("expected the description %q as a Go string literal", description)
+		}
+	}
+	if !strings.Contains(string(formatted), `stringvalidator.OneOf("\"a\"", "b`+"`"+`c")`) {
+		t.Error("expected the enum values as Go string literals")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// manifestFile records the services resources have been generated for.
+const manifestFile = "services.json"
+
+// Manifest tracks the services resources are generated for, so that a service
+// leaving the catalog deprecates its resource instead of silently dropping it.
+type Manifest struct {
+	Services map[string]ManifestEntry `json:"services"`
+}
+
+type ManifestEntry struct {
+	Resource    string `json:"resource"`
+	Fingerprint string `json:"fingerprint"`
+	// Deprecated is the deprecation message of a resource whose service is
+	// no longer published, empty otherwise.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Service is the last catalog entry of a deprecated service, which its
+	// resource is generated from until pruned.
+	Service json.RawMessage `json:"service,omitempty"`
+}
+
+// Changes lists the resources added, changed, deprecated and removed by a run.
+type Changes struct {
+	Added      []string
+	Changed    []string
+	Deprecated []string
+	Removed    []string
+}
+
+func readManifest() (*Manifest, error) {
+	manifest := &Manifest{Services: map[string]ManifestEntry{}}
+	data, err := os.ReadFile(manifestFile)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// readCatalogEntries reads the raw catalog entries of a snapshot by service id.
+// A missing snapshot has no entries.
+func readCatalogEntries(path string) (map[string]json.RawMessage, error) {
+	entries := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var services []json.RawMessage
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		var id struct {
+			ServiceId string `json:"serviceId"`
+		}
+		if err := json.Unmarshal(service, &id); err != nil {
+			return nil, err
+		}
+		entries[id.ServiceId] = service
+	}
+	return entries, nil
+}
+
+// fingerprint identifies what the resource of a catalog entry is generated
+// from, independent of formatting and key order. Catalog fields the generator
+// does not read, e.g. pricing, keywords or option labels, do not change it.
+func fingerprint(entry json.RawMessage) (string, error) {
+	var service Service
+	if err := json.Unmarshal(entry, &service); err != nil {
+		return "", err
+	}
+	type option struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Type        string      `json:"type"`
+		Enum        []string    `json:"enums"`
+		Mandatory   bool        `json:"mandatory"`
+		Sensitive   *bool       `json:"sensitive"`
+		Min         *float64    `json:"min"`
+		Max         *float64    `json:"max"`
+		Default     interface{} `json:"default"`
+		Pattern     string      `json:"pattern"`
+	}
+	generated := struct {
+		ServiceId   string   `json:"serviceId"`
+		Status      string   `json:"status"`
+		Description string   `json:"description"`
+		Options     []option `json:"options"`
+	}{
+		ServiceId:   service.ServiceId,
+		Status:      service.Status,
+		Description: service.Metadata.Description,
+	}
+	for _, o := range service.ServiceInstanceOptions {
+		generated.Options = append(generated.Options, option{
+			Name:        o.Name,
+			Description: o.Description,
+			Type:        o.Type,
+			Enum:        o.Enum,
+			Mandatory:   o.Mandatory,
+			Sensitive:   o.Sensitive,
+			Min:         o.Min,
+			Max:         o.Max,
+			Default:     o.Default,
+			Pattern:     o.Pattern,
+		})
+	}
+	canonical, err := json.Marshal(generated)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// track records a published service, noting whether it is new or changed
+// since the previous manifest.
+func (m *Manifest) track(previous *Manifest, serviceId string, resource string, entry json.RawMessage, changes *Changes) error {
+	sum, err := fingerprint(entry)
+	if err != nil {
+		return err
+	}
+	known, ok := previous.Services[serviceId]
+	switch {
+	case !ok || known.Deprecated != "":
+		changes.Added = append(changes.Added, resource)
+	case known.Fingerprint != sum:
+		changes.Changed = append(changes.Changed, resource)
+	}
+	m.Services[serviceId] = ManifestEntry{Resource: resource, Fingerprint: sum}
+	return nil
+}
+
+// deprecate records a known service that is no longer published. The first
+// time, the deprecation message and the last catalog entry of the service are
+// taken from the current snapshot, if the service is only unpublished, or else
+// from the snapshot before the refresh.
+func (m *Manifest) deprecate(previous *Manifest, serviceId string, entries map[string]json.RawMessage, previousEntries map[string]json.RawMessage, changes *Changes) (ManifestEntry, error) {
+	known := previous.Services[serviceId]
+	if known.Deprecated != "" {
+		m.Services[serviceId] = known
+		return known, nil
+	}
+
+	entry, ok := entries[serviceId]
+	reason := "is no longer published in"
+	if !ok {
+		entry, ok = previousEntries[serviceId]
+		reason = "has been removed from"
+	}
+	if !ok {
+		return known, fmt.Errorf("service %s is in %s but not in %s, restore its catalog entry or remove it from %s", serviceId, manifestFile, catalogSnapshot, manifestFile)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, entry); err != nil {
+		return known, err
+	}
+
+	known.Deprecated = fmt.Sprintf("Service %s %s the OSC catalog. This resource will be removed in a future release.", serviceId, reason)
+	known.Service = compacted.Bytes()
+	m.Services[serviceId] = known
+	changes.Deprecated = append(changes.Deprecated, known.Resource)
+	return known, nil
+}
+
+func (m *Manifest) marshal() ([]byte, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(m); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+func (m *Manifest) write() error {
+	data, err := m.marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestFile, data, 0644)
+}
+
+// check reports whether the manifest on disk differs from this one.
+func (m *Manifest) check() (bool, error) {
+	data, err := m.marshal()
+	if err != nil {
+		return false, err
+	}
+	existing, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return true, err
+	}
+	return !bytes.Equal(existing, data), nil
+}
+
+// section renders the changes as a CHANGELOG.md section, empty if there are
+// none.
+func (c *Changes) section() string {
+	var builder strings.Builder
+	list := func(heading string, resources []string, format string) {
+		if len(resources) == 0 {
+			return
+		}
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(heading + ":\n")
+		for _, resource := range resources {
+			builder.WriteString("- " + fmt.Sprintf(format, resource) + "\n")
+		}
+	}
+	list("BREAKING CHANGES", c.Removed, "`%s` resource removed, its service is no longer in the OSC catalog")
+	list("FEATURES", c.Added, "`%s` resource")
+	list("NOTES", c.Changed, "`%s` resource regenerated from a changed catalog entry")
+	list("DEPRECATIONS", c.Deprecated, "`%s` resource deprecated, its service is no longer in the OSC catalog")
+	return builder.String()
+}
+
+// write prints the changelog section and, if a path is given, writes it there.
+func (c *Changes) write(path string) error {
+	section := c.section()
+	if section == "" {
+		fmt.Println("No services added, changed, deprecated or removed")
+	} else {
+		fmt.Print(section)
+	}
+	if path == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(section), 0644)
+}
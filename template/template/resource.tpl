@@ -3,16 +3,24 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
-
-	osaasclient "github.com/EyevinnOSC/client-go"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	{{- range .Imports}}
+	"{{.}}"
+	{{- end}}
 )
 
 var (
 	_ resource.Resource              = &{{.ObjectName}}{}
 	_ resource.ResourceWithConfigure = &{{.ObjectName}}{}
+	_ resource.ResourceWithImportState = &{{.ObjectName}}{}
+	_ resource.ResourceWithUpgradeState = &{{.ObjectName}}{}
 )
 
 func New{{.ObjectName}}() resource.Resource {
@@ -28,7 +36,7 @@ func (r *{{.ObjectName}}) Configure(ctx context.Context, req resource.ConfigureR
 		return
 	}
 
-	osaasContext, ok := req.ProviderData.(*osaasclient.Context)
+	osaasContext, ok := req.ProviderData.(*oscClient)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -44,7 +52,7 @@ func (r *{{.ObjectName}}) Configure(ctx context.Context, req resource.ConfigureR
 
 // {{.ObjectName}} is the resource implementation.
 type {{.ObjectName}} struct {
-	osaasContext *osaasclient.Context
+	osaasContext *oscClient
 }
 
 type {{.ObjectName}}Model struct {
@@ -52,6 +60,10 @@ type {{.ObjectName}}Model struct {
 	ServiceId              types.String   `tfsdk:"service_id"`
 	ExternalIp				types.String		`tfsdk:"external_ip"`
 	ExternalPort			types.Int32	`tfsdk:"external_port"`
+	Ports					types.List	`tfsdk:"ports"`
+	WaitForReady			types.Bool	`tfsdk:"wait_for_ready"`
+	HealthCheckPath			types.String	`tfsdk:"health_check_path"`
+	Timeouts				timeouts.Value	`tfsdk:"timeouts"`
 	{{- range .InputParameters}}
 	{{.NameInteral}}         {{.Type}}       `tfsdk:"{{.Name}}"`
 	{{- end}}
@@ -62,9 +74,14 @@ func (r *{{.ObjectName}}) Metadata(_ context.Context, req resource.MetadataReque
 }
 
 // Schema defines the schema for the resource.
-func (r *{{.ObjectName}}) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *{{.ObjectName}}) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: `{{.Description}}`,
+		// Version 1 types parameters after the catalog, version 0 saved them as strings
+		Version: 1,
+		MarkdownDescription: {{quote .Description}},
+		{{- if .DeprecationMessage}}
+		DeprecationMessage: {{quote .DeprecationMessage}},
+		{{- end}}
 		Attributes: map[string]schema.Attribute{
 			"instance_url": schema.StringAttribute{
 				Computed: true,
@@ -82,13 +99,76 @@ func (r *{{.ObjectName}}) Schema(_ context.Context, _ resource.SchemaRequest, re
 				Computed: true,
 				Description: "The external Port of the created instance (if available).",
 			},
+			"ports": schema.ListNestedAttribute{
+				Computed: true,
+				Description: "All ports exposed by the created instance.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"internal_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The port inside the instance.",
+						},
+						"external_port": schema.Int32Attribute{
+							Computed: true,
+							Description: "The externally reachable port.",
+						},
+						"external_ip": schema.StringAttribute{
+							Computed: true,
+							Description: "The externally reachable IP.",
+						},
+						"protocol": schema.StringAttribute{
+							Computed: true,
+							Description: "The protocol of the port (if reported by the service).",
+						},
+					},
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default: booldefault.StaticBool(true),
+				Description: "Wait for the instance to be running before creation completes. Defaults to true.",
+			},
+			"health_check_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Path on the instance URL that must answer with a 2xx status before the instance is considered ready.",
+			},
 			{{- range .InputParameters}}
 			"{{.Name}}": schema.{{.SchemaAttribute}}{
+				{{- if eq .SchemaAttribute "ListAttribute"}}
+				ElementType: types.StringType,
+				{{- end}}
 				{{.Flag}}: true,
-				Description: "{{.Description}}",
+				{{- if .Default}}
+				Computed: true,
+				Default: {{.Default}},
+				{{- end}}
+				{{- if .Sensitive}}
+				Sensitive: true,
+				{{- end}}
+				Description: {{quote .Description}},
+				{{- if .Validators}}
+				Validators: []validator.{{.PlanModifierType}}{
+					{{- range .Validators}}
+					{{.}},
+					{{- end}}
+				},
+				{{- end}}
+				{{- if not .Updatable}}
+				PlanModifiers: []planmodifier.{{.PlanModifierType}}{
+					requiresReplace{{.PlanModifierType}}(),
+				},
+				{{- end}}
 			},
 			{{- end}}
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -101,25 +181,53 @@ func (r *{{.ObjectName}}) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("{{.ServiceID}}")
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	instance, err := osaasclient.CreateInstance(r.osaasContext, "{{.ServiceID}}", serviceAccessToken, map[string]interface{}{
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "{{.ServiceID}}"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "{{.ServiceID}}", plan.Name.ValueString()))
+		return
+	}
+
+	parameters := instancePayload(map[string]attr.Value{
 		{{- range .InstanceParameters}}
-		"{{.Name}}": {{.Value}},
+		"{{.Name}}": {{.Field}},
 		{{- end}}
-	})
+	}{{range .InstanceParameters}}{{if .AsArray}}, "{{.Name}}"{{end}}{{end}})
+
+	instance, err := createInstance(ctx, r.osaasContext, "{{.ServiceID}}", parameters)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create instance", err.Error())
+		resp.Diagnostics.AddError("Failed to create instance", errorDetail(err, "{{.ServiceID}}", plan.Name.ValueString()))
 		return
 	}
 
-	ports, err := osaasclient.GetPortsForInstance(r.osaasContext, "{{.ServiceID}}", instance["name"].(string), serviceAccessToken)
+	// The instance exists from here on, so failures still save the state and the instance is tainted rather than lost
+	instanceName := plan.Name.ValueString()
+	if name, ok := instance["name"].(string); ok {
+		instanceName = name
+	}
+	instanceUrl := types.StringNull()
+	if url, ok := instance["url"].(string); ok {
+		instanceUrl = types.StringValue(url)
+	} else {
+		resp.Diagnostics.AddError("Unexpected create instance response", fmt.Sprintf("Expected the URL of instance %q in the response, got: %v", instanceName, instance["url"]))
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "{{.ServiceID}}", instanceName)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get ports for service", err.Error())
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "{{.ServiceID}}", plan.Name.ValueString()))
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
@@ -134,10 +242,14 @@ func (r *{{.ObjectName}}) Create(ctx context.Context, req resource.CreateRequest
 
 	// Update the state with the actual data returned from the API
 	state := {{.ObjectName}}Model{
-		InstanceUrl: types.StringValue(instance["url"].(string)),
+		InstanceUrl: instanceUrl,
 		ServiceId: types.StringValue("{{.ServiceID}}"),
 		ExternalIp: types.StringValue(externalIp),
 		ExternalPort: types.Int32Value(int32(externalPort)),
+		Ports: instancePorts,
+		WaitForReady: plan.WaitForReady,
+		HealthCheckPath: plan.HealthCheckPath,
+		Timeouts: plan.Timeouts,
 		{{- range .InputParameters}}
 		{{.NameInteral}}: {{.Value}},
 		{{- end}}
@@ -149,14 +261,173 @@ func (r *{{.ObjectName}}) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	// The state is already saved, so an instance that never becomes ready is tainted rather than lost
+	if plan.WaitForReady.ValueBool() {
+		err = waitForInstanceReady(ctx, r.osaasContext, "{{.ServiceID}}", instanceName, instanceUrl.ValueString(), plan.HealthCheckPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Instance did not become ready", errorDetail(err, "{{.ServiceID}}", plan.Name.ValueString()))
+			return
+		}
+	}
 }
 
 // Read refreshes the Terraform state with the latest data.
 func (r *{{.ObjectName}}) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state {{.ObjectName}}Model
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "{{.ServiceID}}"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
+		return
+	}
+
+	instance, err := getInstance(ctx, r.osaasContext, "{{.ServiceID}}", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read instance", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
+		return
+	}
+
+	// The instance was removed outside of Terraform, drop it so it is recreated
+	if instance == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	ports, err := getPortsForInstance(ctx, r.osaasContext, "{{.ServiceID}}", state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get ports for service", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
+		return
+	}
+
+	instancePorts, diags := instancePortsValue(ctx, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var externalPort = 0
+	var externalIp = ""
+	if len(ports) > 0 {
+		port := ports[0]
+		externalPort = port.ExternalPort
+		externalIp = port.ExternalIP
+	}
+
+	if instanceUrl, ok := instance["url"].(string); ok {
+		state.InstanceUrl = types.StringValue(instanceUrl)
+	}
+	state.ExternalIp = types.StringValue(externalIp)
+	state.ExternalPort = types.Int32Value(int32(externalPort))
+	state.Ports = instancePorts
+
+	// An imported instance only has its name in state, take the parameters from the API.
+	// Sensitive parameters are left for the configuration to supply.
+	if state.ServiceId.IsNull() {
+		{{- range .InputParameters}}{{if and (ne .Name "name") (not .Sensitive)}}{{if eq .Type "types.String"}}
+		if value, ok := instance["{{.Key}}"].(string); ok && value != "" && state.{{.NameInteral}}.IsNull() {
+			state.{{.NameInteral}} = types.StringValue(value)
+		}
+		{{- end}}{{if eq .Type "types.Bool"}}
+		if value, ok := instance["{{.Key}}"].(bool); ok && state.{{.NameInteral}}.IsNull() {
+			state.{{.NameInteral}} = types.BoolValue(value)
+		}
+		{{- end}}{{if eq .Type "types.Int64"}}
+		if value, ok := int64FromParameter(instance["{{.Key}}"]); ok && state.{{.NameInteral}}.IsNull() {
+			state.{{.NameInteral}} = types.Int64Value(value)
+		}
+		{{- end}}{{if eq .Type "types.List"}}
+		if value, ok := listFromParameter(instance["{{.Key}}"]); ok && state.{{.NameInteral}}.IsNull() {
+			state.{{.NameInteral}} = value
+		}
+		{{- end}}{{if eq .Type "types.Number"}}
+		if value, ok := numberFromParameter(instance["{{.Key}}"]); ok && state.{{.NameInteral}}.IsNull() {
+			state.{{.NameInteral}} = types.NumberValue(value)
+		}
+		{{- end}}{{end}}{{end}}
+		// Setting the attributes left null later on adopts the value rather than replacing the instance
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, unreadParametersKey, unreadParametersValue(importedNullAttributes(map[string]attr.Value{
+			{{- range .InputParameters}}{{if ne .Name "name"}}
+			"{{.Name}}": state.{{.NameInteral}},
+			{{- end}}{{end}}
+		})))...)
+	}
+	state.ServiceId = types.StringValue("{{.ServiceID}}")
+	if state.WaitForReady.IsNull() {
+		state.WaitForReady = types.BoolValue(true)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// UpgradeState upgrades the state saved by earlier versions of the resource.
+func (r *{{.ObjectName}}) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return instanceStateUpgraders()
+}
+
+// ImportState imports an existing instance by its name.
+func (r *{{.ObjectName}}) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	name, err := parseImportId("{{.ServiceID}}", req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import identifier", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// OSC instances cannot be changed in place, every input attribute requires
+// replacement unless configured as updatable, so only the provider side
+// settings and the updatable attributes are recorded in state here, along with
+// attributes that were null in state: not read back by an import, or unset and
+// now filled in with their default.
 func (r *{{.ObjectName}}) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state {{.ObjectName}}Model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.WaitForReady = plan.WaitForReady
+	state.HealthCheckPath = plan.HealthCheckPath
+	state.Timeouts = plan.Timeouts
+	{{- range .InputParameters}}{{if .Updatable}}
+	state.{{.NameInteral}} = plan.{{.NameInteral}}
+	{{- end}}{{end}}
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+{{if gt (len .InputParameters) 1}}
+	adoptParameters(ctx, req, resp,
+		{{- range .InputParameters}}{{if ne .Name "name"}}
+		"{{.Name}}",
+		{{- end}}{{end}}
+	)
+{{- else}}
+	adoptParameters(ctx, req, resp)
+{{- end}}
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -168,15 +439,30 @@ func (r *{{.ObjectName}}) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	serviceAccessToken, err := r.osaasContext.GetServiceAccessToken("{{.ServiceID}}")
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	// Fetched up front so that a missing subscription is reported as such, later calls take it from the cache
+	if _, err := r.osaasContext.serviceAccessToken(ctx, "{{.ServiceID}}"); err != nil {
+		resp.Diagnostics.AddError("Failed to get service access token", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
+		return
+	}
+
+	err := removeInstance(ctx, r.osaasContext, "{{.ServiceID}}", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get service access token", err.Error())
+		resp.Diagnostics.AddError("Failed to delete instance", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
 		return
 	}
 
-	err = osaasclient.RemoveInstance(r.osaasContext, "{{.ServiceID}}", state.Name.ValueString(), serviceAccessToken)
+	err = waitForInstanceRemoved(ctx, r.osaasContext, "{{.ServiceID}}", state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete instance", err.Error())
+		resp.Diagnostics.AddError("Instance was not removed", errorDetail(err, "{{.ServiceID}}", state.Name.ValueString()))
 		return
 	}
 }